@@ -0,0 +1,122 @@
+package winreg
+
+import (
+    "sync"
+    "time"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// AuditPhase tells whether an AuditEvent precedes or follows a mutation.
+type AuditPhase int
+
+const (
+    AuditBefore AuditPhase = iota
+    AuditAfter
+)
+
+// AuditEvent describes a single registry mutation.
+type AuditEvent struct {
+    Time      time.Time
+    Phase     AuditPhase
+    Op        string // name of the package function, e.g. "WriteDWordValue"
+    Root      registry.Key
+    Path      string      // full path, prefixed with the hive name when root is predefined
+    ValueName string      // empty for key operations
+    OldValue  *TypedValue // prior value; nil for key operations or if it didn't exist
+    NewValue  any         // data being written; nil for deletions
+    Err       error       // outcome of the mutation; always nil for AuditBefore
+}
+
+// AuditSink receives an AuditEvent before and after every mutation the
+// package makes: values written or deleted and keys created or deleted.
+// Operations that work on many keys or values report each of them.
+type AuditSink interface {
+    Audit(AuditEvent)
+}
+
+var (
+    auditMu   sync.RWMutex
+    auditSink AuditSink
+)
+
+// SetAuditSink installs s as the receiver of audit events, replacing any
+// previous sink. Passing nil disables auditing. While a sink is set, value
+// writes read the prior value first so it can be reported in OldValue.
+func SetAuditSink(s AuditSink) {
+    auditMu.Lock()
+    defer auditMu.Unlock()
+    auditSink = s
+}
+
+func currentAuditSink() AuditSink {
+    auditMu.RLock()
+    defer auditMu.RUnlock()
+    return auditSink
+}
+
+// auditValue reports the start of a value mutation to the audit sink and
+// returns a function that reports its outcome. It is meant to be deferred
+// with the caller's named error result:
+//
+//    defer auditValue("WriteDWordValue", root, keyPath, valueName, data)(&err)
+func auditValue(op string, root registry.Key, keyPath, valueName string, newValue any) func(*error) {
+    sink := currentAuditSink()
+    if sink == nil {
+        return func(*error) {}
+    }
+
+    ev := AuditEvent{
+        Op:        op,
+        Root:      root,
+        Path:      fullKeyPath(root, keyPath),
+        ValueName: valueName,
+        NewValue:  newValue,
+    }
+    if k, err := openKey(root, keyPath, registry.QUERY_VALUE); err == nil {
+        if v, err := readTypedValue(k, valueName); err == nil {
+            ev.OldValue = &v
+        }
+        k.Close()
+    }
+
+    return emitAudit(sink, ev)
+}
+
+// auditKey is auditValue for operations on a whole key.
+func auditKey(op string, root registry.Key, keyPath string) func(*error) {
+    sink := currentAuditSink()
+    if sink == nil {
+        return func(*error) {}
+    }
+
+    return emitAudit(sink, AuditEvent{Op: op, Root: root, Path: fullKeyPath(root, keyPath)})
+}
+
+// emitAudit sends ev as the AuditBefore event and returns a function that
+// sends the matching AuditAfter event.
+func emitAudit(sink AuditSink, ev AuditEvent) func(*error) {
+    ev.Time = time.Now()
+    ev.Phase = AuditBefore
+    sink.Audit(ev)
+
+    return func(errp *error) {
+        ev.Time = time.Now()
+        ev.Phase = AuditAfter
+        ev.Err = *errp
+        sink.Audit(ev)
+    }
+}
+
+// fullKeyPath prefixes keyPath with the name of root when root is one of the
+// predefined keys.
+func fullKeyPath(root registry.Key, keyPath string) string {
+    name, ok := rootKeyNames[root]
+    if !ok {
+        return cleanPath(keyPath)
+    }
+    if p := cleanPath(keyPath); p != "" {
+        return name + `\` + p
+    }
+    return name
+}
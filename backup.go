@@ -0,0 +1,39 @@
+package winreg
+
+import (
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// OpenOptions controls OpenKeyWith.
+type OpenOptions struct {
+    // BackupRestore opens the key with REG_OPTION_BACKUP_RESTORE, which
+    // bypasses its security descriptor for a caller holding the backup or
+    // restore privilege, so that backup tools can read keys whose ACLs
+    // deny them. The requested access is then ignored: with the backup
+    // privilege the key is opened for reading, with the restore privilege
+    // for writing and deletion. SeBackupPrivilege is enabled in the process
+    // token, and SeRestorePrivilege too when access asks for any write
+    // right; the caller must hold them, which normally means running as an
+    // administrator or backup operator.
+    BackupRestore bool
+}
+
+// OpenKeyWith opens the key at keyPath like registry.OpenKey, with the
+// special behaviour selected by opts. The caller must close the returned
+// key.
+func OpenKeyWith(root registry.Key, keyPath string, access uint32, opts OpenOptions) (registry.Key, error) {
+    var options uint32
+    if opts.BackupRestore {
+        privileges := []string{"SeBackupPrivilege"}
+        if access&(registry.SET_VALUE|registry.CREATE_SUB_KEY|registry.CREATE_LINK|windows.DELETE) != 0 {
+            privileges = append(privileges, "SeRestorePrivilege")
+        }
+        if err := enablePrivileges(privileges...); err != nil {
+            return 0, err
+        }
+        options |= regOptionBackupRestore
+    }
+
+    return openKeyEx(root, keyPath, options, access)
+}
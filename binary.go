@@ -0,0 +1,149 @@
+package winreg
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+    "syscall"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// WriteBinaryValueFrom reads up to size bytes from r and writes them as a
+// BINARY value. Reading stops early, without error, if r reaches EOF first.
+func WriteBinaryValueFrom(root registry.Key, keyPath, valueName string, r io.Reader, size int) (err error) {
+    if size < 0 {
+        return fmt.Errorf("winreg: negative size %d", size)
+    }
+
+    data := make([]byte, size)
+    n, err := io.ReadFull(r, data)
+    if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+        return err
+    }
+    data = data[:n]
+
+    defer auditValue("WriteBinaryValueFrom", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return k.SetBinaryValue(valueName, data)
+}
+
+// ReadBinaryValueTo reads a BINARY value and writes its data to w. It
+// returns the number of bytes written.
+func ReadBinaryValueTo(root registry.Key, keyPath, valueName string, w io.Writer) (int64, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return 0, err
+    }
+    defer k.Close()
+
+    data, typ, err := readRawValue(k, valueName)
+    if err != nil {
+        return 0, err
+    }
+    if typ != registry.BINARY {
+        return 0, registry.ErrUnexpectedType
+    }
+
+    n, err := w.Write(data)
+    return int64(n), err
+}
+
+// ReadBinaryAs decodes a BINARY value into dest, a pointer to a fixed-size
+// struct or other fixed-size data, with binary.Read in little-endian byte
+// order. It fails if the size of the value differs from the size of dest.
+func ReadBinaryAs(root registry.Key, keyPath, valueName string, dest any) error {
+    data, err := ReadBinaryValue(root, keyPath, valueName)
+    if err != nil {
+        return err
+    }
+
+    if size := binary.Size(dest); size != len(data) {
+        return fmt.Errorf("winreg: value %q has %d bytes, want %d for %T", valueName, len(data), size, dest)
+    }
+
+    return binary.Read(bytes.NewReader(data), binary.LittleEndian, dest)
+}
+
+// WriteBinaryAs encodes data, a fixed-size struct or other fixed-size data,
+// with binary.Write in little-endian byte order and writes it as a BINARY
+// value.
+func WriteBinaryAs(root registry.Key, keyPath, valueName string, data any) error {
+    var buf bytes.Buffer
+    if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+        return err
+    }
+
+    return WriteBinaryValue(root, keyPath, valueName, buf.Bytes())
+}
+
+// ReadBinaryValueHex reads a BINARY value as an upper-case hex string
+// without separators.
+func ReadBinaryValueHex(root registry.Key, keyPath, valueName string) (string, error) {
+    data, err := ReadBinaryValue(root, keyPath, valueName)
+    if err != nil {
+        return "", err
+    }
+    return strings.ToUpper(hex.EncodeToString(data)), nil
+}
+
+// WriteBinaryValueHex decodes a hex string and writes the bytes as a BINARY
+// value. Either case is accepted, and whitespace as well as ':', '-' and ','
+// separators are ignored, so "DE AD BE EF" and "de:ad:be:ef" both work.
+func WriteBinaryValueHex(root registry.Key, keyPath, valueName, hexData string) error {
+    hexData = strings.Map(func(r rune) rune {
+        switch r {
+        case ' ', '\t', '\r', '\n', ':', '-', ',':
+            return -1
+        }
+        return r
+    }, hexData)
+
+    data, err := hex.DecodeString(hexData)
+    if err != nil {
+        return fmt.Errorf("winreg: malformed hex data: %w", err)
+    }
+
+    return WriteBinaryValue(root, keyPath, valueName, data)
+}
+
+// ErrValueTooLarge is returned by ReadBinaryValueInto when the value does not
+// fit into the buffer.
+var ErrValueTooLarge = errors.New("winreg: value too large for buffer")
+
+// ReadBinaryValueInto reads the raw data of a value of any type into buf
+// without allocating, and returns the number of bytes read and the value's
+// type. If buf is too small, n is the size needed and ErrValueTooLarge is
+// returned, so that the caller can grow the buffer and retry:
+//
+//    n, typ, err := ReadBinaryValueInto(root, keyPath, name, buf)
+//    if err == ErrValueTooLarge {
+//        buf = make([]byte, n)
+//        n, typ, err = ReadBinaryValueInto(root, keyPath, name, buf)
+//    }
+func ReadBinaryValueInto(root registry.Key, keyPath, valueName string, buf []byte) (n int, typ uint32, err error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return 0, 0, err
+    }
+    defer k.Close()
+
+    n, typ, err = k.GetValue(valueName, buf)
+    if err == syscall.ERROR_MORE_DATA || err == nil && n > len(buf) {
+        return n, typ, ErrValueTooLarge
+    }
+    if err != nil {
+        return 0, 0, err
+    }
+    return n, typ, nil
+}
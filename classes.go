@@ -0,0 +1,57 @@
+package winreg
+
+import (
+    "golang.org/x/sys/windows/registry"
+)
+
+// classesPath is the location of the per-user and per-machine class
+// registrations that HKEY_CLASSES_ROOT merges.
+const classesPath = `Software\Classes`
+
+// ClassesRootUserPath returns the root and path of the per-user class
+// registration for subPath, i.e. HKCU\Software\Classes\subPath.
+func ClassesRootUserPath(subPath string) (registry.Key, string) {
+    return registry.CURRENT_USER, joinClassesPath(subPath)
+}
+
+// ClassesRootMachinePath returns the root and path of the machine-wide class
+// registration for subPath, i.e. HKLM\Software\Classes\subPath.
+func ClassesRootMachinePath(subPath string) (registry.Key, string) {
+    return registry.LOCAL_MACHINE, joinClassesPath(subPath)
+}
+
+func joinClassesPath(subPath string) string {
+    if p := cleanPath(subPath); p != "" {
+        return classesPath + `\` + p
+    }
+    return classesPath
+}
+
+// ReadClassesRoot reads a value through the merged HKEY_CLASSES_ROOT view,
+// where a per-user registration takes precedence over the machine-wide one.
+//
+// Reading through HKEY_CLASSES_ROOT is fine, but writing through it is not
+// predictable: a write lands in HKCU if the key already exists there and in
+// HKLM otherwise. To write, pick the target explicitly with
+// ClassesRootUserPath or ClassesRootMachinePath.
+func ReadClassesRoot(subPath, valueName string) (TypedValue, error) {
+    k, err := openKey(registry.CLASSES_ROOT, subPath, registry.QUERY_VALUE)
+    if err != nil {
+        return TypedValue{}, err
+    }
+    defer k.Close()
+
+    return readTypedValue(k, valueName)
+}
+
+// ReadUserClasses reads a value from the class registrations of the user
+// identified by sid, i.e. HKEY_USERS\<sid>\Software\Classes\subPath. Unlike
+// HKEY_CLASSES_ROOT this works for users other than the caller. The data is
+// returned in the form documented on ReadValue. ErrHiveNotLoaded is returned
+// if the user's hive is not loaded.
+func ReadUserClasses(sid, subPath, valueName string) (any, error) {
+    if !KeyExists(registry.USERS, sid) {
+        return nil, ErrHiveNotLoaded
+    }
+    return ReadValue(registry.USERS, sid+`\`+joinClassesPath(subPath), valueName)
+}
@@ -0,0 +1,34 @@
+package winreg
+
+import (
+    "fmt"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// ReadControlSetValue reads a value from the active control set without going
+// through the CurrentControlSet link. subPath is relative to the control
+// set, e.g. `Services\Tcpip\Parameters`. The active set is taken from
+// HKLM\SYSTEM\Select\Current and the value read from the matching
+// SYSTEM\ControlSet00N key. The data is returned in the form documented on
+// ReadValue.
+func ReadControlSetValue(subPath, valueName string) (any, error) {
+    path, err := activeControlSetPath()
+    if err != nil {
+        return nil, err
+    }
+    if p := cleanPath(subPath); p != "" {
+        path += `\` + p
+    }
+    return ReadValue(registry.LOCAL_MACHINE, path, valueName)
+}
+
+// activeControlSetPath returns the path of the active control set relative to
+// HKEY_LOCAL_MACHINE, such as `SYSTEM\ControlSet001`.
+func activeControlSetPath() (string, error) {
+    current, err := ReadDWordValue(registry.LOCAL_MACHINE, `SYSTEM\Select`, "Current")
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf(`SYSTEM\ControlSet%03d`, current), nil
+}
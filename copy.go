@@ -0,0 +1,42 @@
+package winreg
+
+import (
+    "golang.org/x/sys/windows/registry"
+)
+
+// CopyValuesFunc copies the values of the source key for which include
+// returns true to the destination key, creating it if needed. Values keep
+// their type and bytes exactly.
+func CopyValuesFunc(srcRoot registry.Key, srcPath string, dstRoot registry.Key, dstPath string, include func(name string, typ uint32) bool) error {
+    src, err := openKey(srcRoot, srcPath, registry.QUERY_VALUE)
+    if err != nil {
+        return err
+    }
+    defer src.Close()
+
+    dst, err := createKeyAudited("CopyValuesFunc", dstRoot, dstPath, registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer dst.Close()
+
+    names, err := src.ReadValueNames(-1)
+    if err != nil {
+        return err
+    }
+
+    for _, name := range names {
+        raw, typ, err := readRawValue(src, name)
+        if err != nil {
+            return err
+        }
+        if !include(name, typ) {
+            continue
+        }
+        if err := setAudited("CopyValuesFunc", dstRoot, dstPath, dst, name, typ, raw, decodeValue(typ, raw)); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,49 @@
+package winreg
+
+import (
+    "errors"
+    "fmt"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// Deletion names a key or value for ApplyDeletions. If ValueName is nil the
+// key at Path is deleted together with everything beneath it; otherwise
+// only the named value is deleted, where an empty name is the default value.
+type Deletion struct {
+    Root      registry.Key
+    Path      string
+    ValueName *string
+}
+
+// ApplyDeletions deletes every key and value listed, like the [-KEY] and
+// "name"=- entries of a .reg file. Targets that are already missing are
+// skipped. A failure does not stop the remaining deletions; all failures
+// are returned together, joined with errors.Join.
+func ApplyDeletions(deletions []Deletion) error {
+    var errs []error
+    for _, d := range deletions {
+        err := applyDeletion(d)
+        if err == nil || err == registry.ErrNotExist {
+            continue
+        }
+        target := fullKeyPath(d.Root, d.Path)
+        if d.ValueName != nil {
+            target += fmt.Sprintf(" value %q", *d.ValueName)
+        }
+        errs = append(errs, fmt.Errorf("winreg: delete %s: %w", target, err))
+    }
+
+    return errors.Join(errs...)
+}
+
+// applyDeletion performs a single deletion.
+func applyDeletion(d Deletion) (err error) {
+    if d.ValueName != nil {
+        return DeleteValue(d.Root, d.Path, *d.ValueName)
+    }
+
+    defer auditKey("ApplyDeletions", d.Root, d.Path)(&err)
+
+    return deleteKeyTreeAt(d.Root, d.Path)
+}
@@ -0,0 +1,48 @@
+package winreg
+
+import (
+    "os"
+    "strconv"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// ApplyFromEnv writes every environment variable whose name starts with
+// prefix as a value of the key at keyPath, named after the rest of the
+// variable name. The prefix is matched case-insensitively, as Windows treats
+// variable names. Data that is a decimal number fitting in 32 bits is
+// written as a DWORD and anything else as an SZ string, so MYAPP_Port=8080
+// becomes the DWORD Port with prefix "MYAPP_". A variable named exactly
+// prefix is ignored.
+func ApplyFromEnv(root registry.Key, keyPath, prefix string) error {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    for _, kv := range os.Environ() {
+        name, data, ok := strings.Cut(kv, "=")
+        // Windows keeps per-drive working directories in variables like
+        // "=C:", which Environ returns with an empty name.
+        if !ok || name == "" || len(name) <= len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+            continue
+        }
+        valueName := name[len(prefix):]
+
+        var typed any = data
+        if n, err := strconv.ParseUint(data, 10, 32); err == nil {
+            typed = uint32(n)
+        }
+        typ, raw, err := encodeGeneric(typed)
+        if err != nil {
+            return err
+        }
+        if err := setAudited("ApplyFromEnv", root, keyPath, k, valueName, typ, raw, typed); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,32 @@
+package winreg
+
+import (
+    "errors"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// ErrValueNotFound is returned when a value, or the key holding it, does not
+// exist. It is the same error as registry.ErrNotExist, so either can be
+// used with errors.Is.
+var ErrValueNotFound = registry.ErrNotExist
+
+// IsLikelyElevationIssue reports whether err is an access-denied error on a
+// machine-wide hive while the current process is not elevated, in which case
+// running as administrator will probably help. Access denied under
+// HKEY_CURRENT_USER, or from an already elevated process, points to an ACL
+// problem instead and yields false.
+func IsLikelyElevationIssue(err error, root registry.Key) bool {
+    if !errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+        return false
+    }
+
+    switch root {
+    case registry.LOCAL_MACHINE, registry.USERS, registry.CLASSES_ROOT, registry.CURRENT_CONFIG:
+    default:
+        return false
+    }
+
+    return !windows.GetCurrentProcessToken().IsElevated()
+}
@@ -0,0 +1,328 @@
+package winreg
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/json"
+    "errors"
+    "io"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// errUnknownRoot is returned when a root key has no HKEY_* name.
+var errUnknownRoot = errors.New("winreg: root is not a predefined key")
+
+// ExportToRegFile writes the key at keyPath and its whole subtree to w in the
+// format of regedit's "Windows Registry Editor Version 5.00" files, encoded
+// as UTF-16LE with a byte order mark. Output is written incrementally and
+// flushed after each key, so the subtree is never held in memory.
+func ExportToRegFile(root registry.Key, keyPath string, w io.Writer) error {
+    rootName, ok := rootKeyNames[root]
+    if !ok {
+        return errUnknownRoot
+    }
+
+    k, err := openKey(root, keyPath, registry.READ)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    fullPath := rootName
+    if p := cleanPath(keyPath); p != "" {
+        fullPath += `\` + p
+    }
+
+    rw := &regFileWriter{w: bufio.NewWriter(w)}
+    rw.WriteString("\ufeffWindows Registry Editor Version 5.00\r\n")
+    if err := exportRegKey(rw, k, fullPath); err != nil {
+        return err
+    }
+
+    return rw.w.Flush()
+}
+
+// exportRegKey writes the open key k, known as fullPath, and its subkeys.
+func exportRegKey(rw *regFileWriter, k registry.Key, fullPath string) error {
+    rw.WriteString("\r\n[" + fullPath + "]\r\n")
+
+    names, err := k.ReadValueNames(-1)
+    if err != nil {
+        return err
+    }
+    for _, name := range names {
+        raw, typ, err := readRawValue(k, name)
+        if err != nil {
+            return err
+        }
+        rw.WriteString(formatRegFileValue(name, typ, raw))
+    }
+    if err := rw.w.Flush(); err != nil {
+        return err
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return err
+    }
+    for _, name := range subKeys {
+        sk, err := openKey(k, name, registry.READ)
+        if err != nil {
+            return err
+        }
+        err = exportRegKey(rw, sk, fullPath+`\`+name)
+        sk.Close()
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// ExportToJSON writes the key at keyPath and its whole subtree to w as a JSON
+// object of the form
+//
+//    {
+//      "values": {"Name": {"type": "REG_SZ", "data": "..."}},
+//      "subkeys": {"Child": {"values": {...}, "subkeys": {...}}}
+//    }
+//
+// String types are written as strings, MULTI_SZ as an array of strings,
+// DWORD and QWORD as numbers and everything else as base64. Output is
+// written incrementally and flushed after each key, so the subtree is never
+// held in memory.
+func ExportToJSON(root registry.Key, keyPath string, w io.Writer) error {
+    k, err := openKey(root, keyPath, registry.READ)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    bw := bufio.NewWriter(w)
+    if err := exportJSONKey(bw, k, ""); err != nil {
+        return err
+    }
+    bw.WriteString("\n")
+
+    return bw.Flush()
+}
+
+// exportJSONKey writes the open key k as a JSON object indented by indent.
+func exportJSONKey(bw *bufio.Writer, k registry.Key, indent string) error {
+    bw.WriteString("{\n" + indent + `  "values": {`)
+
+    names, err := k.ReadValueNames(-1)
+    if err != nil {
+        return err
+    }
+    for i, name := range names {
+        raw, typ, err := readRawValue(k, name)
+        if err != nil {
+            return err
+        }
+        v, err := jsonValue(typ, raw)
+        if err != nil {
+            return err
+        }
+        if i > 0 {
+            bw.WriteString(",")
+        }
+        bw.WriteString("\n" + indent + "    " + jsonString(name) + ": " + v)
+    }
+    if len(names) > 0 {
+        bw.WriteString("\n" + indent + "  ")
+    }
+    bw.WriteString("},\n" + indent + `  "subkeys": {`)
+    if err := bw.Flush(); err != nil {
+        return err
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return err
+    }
+    for i, name := range subKeys {
+        if i > 0 {
+            bw.WriteString(",")
+        }
+        bw.WriteString("\n" + indent + "    " + jsonString(name) + ": ")
+
+        sk, err := openKey(k, name, registry.READ)
+        if err != nil {
+            return err
+        }
+        err = exportJSONKey(bw, sk, indent+"    ")
+        sk.Close()
+        if err != nil {
+            return err
+        }
+    }
+    if len(subKeys) > 0 {
+        bw.WriteString("\n" + indent + "  ")
+    }
+    bw.WriteString("}\n" + indent + "}")
+
+    return bw.Flush()
+}
+
+// ExportToYAML writes the key at keyPath and everything beneath it to w as
+// YAML, in the same shape as ExportToJSON:
+//
+//    values:
+//      "Name":
+//        type: REG_SZ
+//        data: "text"
+//      "Path":
+//        type: REG_MULTI_SZ
+//        data:
+//          - "a"
+//          - "b"
+//    subkeys:
+//      "Child":
+//        values: {}
+//        subkeys: {}
+//
+// Data is encoded as described on ExportToJSON. Names and strings are always
+// double-quoted, so none of them is mistaken for a number or boolean when
+// the document is edited and read back. Like ExportToJSON, output is written
+// incrementally.
+func ExportToYAML(root registry.Key, keyPath string, w io.Writer) error {
+    k, err := openKey(root, keyPath, registry.READ)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    bw := bufio.NewWriter(w)
+    if err := exportYAMLKey(bw, k, ""); err != nil {
+        return err
+    }
+
+    return bw.Flush()
+}
+
+// exportYAMLKey writes the open key k as a YAML mapping indented by indent.
+func exportYAMLKey(bw *bufio.Writer, k registry.Key, indent string) error {
+    names, err := k.ReadValueNames(-1)
+    if err != nil {
+        return err
+    }
+    if len(names) == 0 {
+        bw.WriteString(indent + "values: {}\n")
+    } else {
+        bw.WriteString(indent + "values:\n")
+    }
+    for _, name := range names {
+        raw, typ, err := readRawValue(k, name)
+        if err != nil {
+            return err
+        }
+        bw.WriteString(indent + "  " + yamlString(name) + ":\n")
+        writeYAMLValue(bw, typ, raw, indent+"    ")
+    }
+    if err := bw.Flush(); err != nil {
+        return err
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return err
+    }
+    if len(subKeys) == 0 {
+        bw.WriteString(indent + "subkeys: {}\n")
+    } else {
+        bw.WriteString(indent + "subkeys:\n")
+    }
+    for _, name := range subKeys {
+        bw.WriteString(indent + "  " + yamlString(name) + ":\n")
+
+        sk, err := openKey(k, name, registry.READ)
+        if err != nil {
+            return err
+        }
+        err = exportYAMLKey(bw, sk, indent+"    ")
+        sk.Close()
+        if err != nil {
+            return err
+        }
+    }
+
+    return bw.Flush()
+}
+
+// KeyValuesToJSON writes the values of the single key at keyPath to w as a
+// JSON object mapping each name to {"type": ..., "data": ...}, encoded as
+// described on ExportToJSON. Subkeys are not included. Names are sorted
+// case-insensitively so that snapshots of the same key diff cleanly.
+func KeyValuesToJSON(root registry.Key, keyPath string, w io.Writer) error {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    names, err := k.ReadValueNames(-1)
+    if err != nil {
+        return err
+    }
+    sortFold(names)
+
+    bw := bufio.NewWriter(w)
+    bw.WriteString("{")
+    for i, name := range names {
+        raw, typ, err := readRawValue(k, name)
+        if err != nil {
+            return err
+        }
+        v, err := jsonValue(typ, raw)
+        if err != nil {
+            return err
+        }
+        if i > 0 {
+            bw.WriteString(",")
+        }
+        bw.WriteString("\n  " + jsonString(name) + ": " + v)
+    }
+    if len(names) > 0 {
+        bw.WriteString("\n")
+    }
+    bw.WriteString("}\n")
+
+    return bw.Flush()
+}
+
+// jsonTypedValue is the JSON form of a single value.
+type jsonTypedValue struct {
+    Type string `json:"type"`
+    Data any    `json:"data"`
+}
+
+// jsonValue returns the JSON encoding of a value as {"type": ..., "data": ...}.
+func jsonValue(typ uint32, raw []byte) (string, error) {
+    data := decodeValue(typ, raw)
+    if ss, ok := data.([]string); ok && ss == nil {
+        data = []string{}
+    }
+    return marshalJSON(jsonTypedValue{Type: TypeName(typ), Data: data})
+}
+
+// jsonString returns s as a JSON string literal.
+func jsonString(s string) string {
+    b, _ := marshalJSON(s)
+    return b
+}
+
+// marshalJSON is json.Marshal without HTML escaping, so paths and command
+// lines stay readable.
+func marshalJSON(v any) (string, error) {
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    enc.SetEscapeHTML(false)
+    if err := enc.Encode(v); err != nil {
+        return "", err
+    }
+    return strings.TrimSuffix(buf.String(), "\n"), nil
+}
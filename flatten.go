@@ -0,0 +1,155 @@
+package winreg
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// Flatten reads the subtree at keyPath into a flat map. Each value's entry is
+// named by its subkey path relative to keyPath and its value name, joined
+// with sep, e.g. "Sub.Deep.Name" with sep ".". A default value gets an empty
+// name, so it ends in sep, and the default value of keyPath itself is
+// stored under "". The data is in the form documented on ReadValue, which
+// Unflatten writes back with the same type.
+//
+// Choose a sep that doesn't occur in key or value names, since such names
+// can't be told apart from nesting.
+func Flatten(root registry.Key, keyPath, sep string) (map[string]any, error) {
+    base := cleanPath(keyPath)
+    flat := make(map[string]any)
+
+    err := WalkKeys(root, keyPath, func(path string) error {
+        k, err := openKey(root, path, registry.QUERY_VALUE)
+        if err != nil {
+            return err
+        }
+        defer k.Close()
+
+        prefix := ""
+        if rel := strings.TrimPrefix(path[len(base):], `\`); rel != "" {
+            prefix = strings.ReplaceAll(rel, `\`, sep) + sep
+        }
+
+        names, err := k.ReadValueNames(-1)
+        if err != nil {
+            return err
+        }
+        for _, name := range names {
+            raw, typ, err := readRawValue(k, name)
+            if err != nil {
+                return err
+            }
+            flat[prefix+name] = genericValue(typ, raw)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return flat, nil
+}
+
+// Unflatten writes a map in the shape produced by Flatten back into the
+// registry beneath keyPath. Each entry name is split at its last sep into a
+// subkey path and a value name; the subkeys are created as needed and empty
+// path segments are ignored. An empty value name, as in "Sub.", or the
+// entry "" addresses a default value.
+//
+// The registry type follows from the Go type of the data as listed on
+// ReadValue. For convenience with configuration libraries, int, int32,
+// int64 and uint are written as a DWORD when they fit and as a QWORD
+// otherwise, and bool is written as a DWORD 0 or 1. A float64, which is
+// what encoding/json decodes numbers into, is treated the same way if it
+// is a whole number; a fraction is an error.
+func Unflatten(root registry.Key, keyPath string, flat map[string]any, sep string) error {
+    names := make([]string, 0, len(flat))
+    for name := range flat {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        subPath, valueName := "", name
+        if i := strings.LastIndex(name, sep); i >= 0 && sep != "" {
+            subPath, valueName = strings.ReplaceAll(name[:i], sep, `\`), name[i+len(sep):]
+        }
+        path := joinKeyPath(cleanPath(keyPath), cleanPath(subPath))
+
+        data, err := unflattenValue(flat[name])
+        if err != nil {
+            return fmt.Errorf("winreg: entry %q: %w", name, err)
+        }
+        typ, raw, err := encodeGeneric(data)
+        if err != nil {
+            return fmt.Errorf("winreg: entry %q: %w", name, err)
+        }
+
+        k, err := createKeyAudited("Unflatten", root, path, registry.SET_VALUE)
+        if err != nil {
+            return err
+        }
+        err = setAudited("Unflatten", root, path, k, valueName, typ, raw, data)
+        k.Close()
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// unflattenValue converts the plain Go integer and bool types to the forms
+// accepted by WriteValue.
+func unflattenValue(data any) (any, error) {
+    var n int64
+    switch v := data.(type) {
+    case bool:
+        if v {
+            return uint32(1), nil
+        }
+        return uint32(0), nil
+    case int:
+        n = int64(v)
+    case int32:
+        n = int64(v)
+    case int64:
+        n = v
+    case uint:
+        return widenUint(uint64(v)), nil
+    case float64:
+        switch {
+        case v != math.Trunc(v) || math.IsInf(v, 0):
+            return nil, fmt.Errorf("number %v is not a whole number", v)
+        case v >= 0 && v < 1<<64:
+            return widenUint(uint64(v)), nil
+        case v < 0 && v >= math.MinInt64:
+            n = int64(v)
+        default:
+            return nil, fmt.Errorf("number %v does not fit in a QWORD", v)
+        }
+    default:
+        return data, nil
+    }
+    if n < 0 {
+        // Negative numbers are stored in two's complement, as
+        // WriteInt32Value and WriteInt64Value do.
+        if n >= math.MinInt32 {
+            return uint32(n), nil
+        }
+        return uint64(n), nil
+    }
+    return widenUint(uint64(n)), nil
+}
+
+// widenUint returns n as a uint32 if it fits and as a uint64 otherwise.
+func widenUint(n uint64) any {
+    if n <= 0xffffffff {
+        return uint32(n)
+    }
+    return n
+}
@@ -0,0 +1,252 @@
+package winreg
+
+import (
+    "fmt"
+    "sync"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// NoneValue is the data of a REG_NONE value. It lets WriteValue tell REG_NONE
+// apart from REG_BINARY, which a plain []byte selects.
+type NoneValue []byte
+
+// ExpandString is the data of a REG_EXPAND_SZ value. It lets WriteValue tell
+// REG_EXPAND_SZ apart from REG_SZ, which a plain string selects.
+type ExpandString string
+
+// BigEndianDWord is the data of a REG_DWORD_BIG_ENDIAN value. It lets
+// WriteValue tell it apart from REG_DWORD, which a plain uint32 selects.
+type BigEndianDWord uint32
+
+// ReadValue reads a value of any type. The Go type of the result identifies
+// the registry type, so that passing it back to WriteValue recreates the
+// value exactly:
+//
+//    string          REG_SZ
+//    ExpandString    REG_EXPAND_SZ
+//    []string        REG_MULTI_SZ
+//    uint32          REG_DWORD
+//    BigEndianDWord  REG_DWORD_BIG_ENDIAN
+//    uint64          REG_QWORD
+//    []byte          REG_BINARY
+//    NoneValue       REG_NONE
+//    TypedValue      any other type, or malformed integer data
+//
+// Decoders added with RegisterDecoder take precedence over these forms.
+func ReadValue(root registry.Key, keyPath, valueName string) (any, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    raw, typ, err := readRawValue(k, valueName)
+    if err != nil {
+        return nil, err
+    }
+
+    return decodeGeneric(keyPath, valueName, typ, raw)
+}
+
+// WriteValue writes data with the registry type selected by its Go type, as
+// listed on ReadValue.
+func WriteValue(root registry.Key, keyPath, valueName string, data any) (err error) {
+    defer auditValue("WriteValue", root, keyPath, valueName, data)(&err)
+
+    typ, raw, err := encodeGeneric(data)
+    if err != nil {
+        return err
+    }
+
+    k, err := openKey(root, keyPath, registry.WRITE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return regSetValueEx(k, valueName, typ, raw)
+}
+
+// WithValue writes data as WriteValue does, runs fn and then restores the
+// value to its previous state: the prior data and type, or no value at all
+// if it didn't exist. The value is restored even if fn panics. fn's error is
+// returned; a failure to restore is returned only if fn succeeded.
+func WithValue(root registry.Key, keyPath, valueName string, data any, fn func() error) (err error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return err
+    }
+    prev, prevType, err := readRawValue(k, valueName)
+    k.Close()
+    existed := err == nil
+    if err != nil && err != registry.ErrNotExist {
+        return err
+    }
+
+    if err := WriteValue(root, keyPath, valueName, data); err != nil {
+        return err
+    }
+    defer func() {
+        var rerr error
+        if existed {
+            rerr = WriteTypedValue(root, keyPath, valueName, TypedValue{Type: prevType, Raw: prev})
+        } else {
+            rerr = DeleteValue(root, keyPath, valueName)
+        }
+        if err == nil {
+            err = rerr
+        }
+    }()
+
+    return fn()
+}
+
+// ReadValuesFromKeys reads the named values from each of the keys, returning
+// them keyed by key path and then by value name, in the form documented on
+// ReadValue. Keys and values that don't exist are left out; keys with none
+// of the values are not included at all.
+func ReadValuesFromKeys(root registry.Key, keyPaths []string, valueNames []string) (map[string]map[string]any, error) {
+    result := make(map[string]map[string]any)
+    for _, keyPath := range keyPaths {
+        k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+        if err == registry.ErrNotExist {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        values := make(map[string]any)
+        for _, name := range valueNames {
+            raw, typ, err := readRawValue(k, name)
+            if err == registry.ErrNotExist {
+                continue
+            }
+            if err != nil {
+                k.Close()
+                return nil, err
+            }
+            if values[name], err = decodeGeneric(keyPath, name, typ, raw); err != nil {
+                k.Close()
+                return nil, err
+            }
+        }
+        k.Close()
+
+        if len(values) > 0 {
+            result[keyPath] = values
+        }
+    }
+
+    return result, nil
+}
+
+// decoder is a value decoder added with RegisterDecoder.
+type decoder struct {
+    match  func(keyPath, valueName string, typ uint32) bool
+    decode func([]byte) (any, error)
+}
+
+var (
+    decodersMu sync.RWMutex
+    decoders   []decoder
+)
+
+// RegisterDecoder teaches ReadValue and ReadValuesFromKeys to decode
+// application-specific data. For every value read, match is called with the
+// key path as passed by the caller, the value name and the value type; the
+// first decoder whose match returns true decodes the raw bytes, and its
+// result or error is returned instead of the standard form. Decoders are
+// consulted in the order they were registered. RegisterDecoder is safe to
+// call concurrently with reads.
+func RegisterDecoder(match func(keyPath, valueName string, typ uint32) bool, decode func([]byte) (any, error)) {
+    decodersMu.Lock()
+    defer decodersMu.Unlock()
+    decoders = append(decoders, decoder{match: match, decode: decode})
+}
+
+// decodeGeneric converts raw value bytes with the first matching registered
+// decoder, or to the form returned by genericValue if none matches.
+func decodeGeneric(keyPath, valueName string, typ uint32, raw []byte) (any, error) {
+    decodersMu.RLock()
+    ds := decoders
+    decodersMu.RUnlock()
+
+    for _, d := range ds {
+        if d.match(keyPath, valueName, typ) {
+            return d.decode(raw)
+        }
+    }
+    return genericValue(typ, raw), nil
+}
+
+// genericValue converts raw value bytes to the Go form returned by ReadValue.
+func genericValue(typ uint32, raw []byte) any {
+    data := decodeValue(typ, raw)
+    switch typ {
+    case registry.SZ, registry.MULTI_SZ, registry.BINARY:
+        return data
+    case registry.DWORD, registry.QWORD:
+        if _, ok := data.([]byte); !ok {
+            return data
+        }
+    case registry.DWORD_BIG_ENDIAN:
+        if v, ok := data.(uint32); ok {
+            return BigEndianDWord(v)
+        }
+    case registry.EXPAND_SZ:
+        return ExpandString(data.(string))
+    case registry.NONE:
+        return NoneValue(raw)
+    }
+    return TypedValue{Type: typ, Data: data, Raw: raw}
+}
+
+// encodeGeneric returns the registry type and bytes for data in one of the
+// forms listed on ReadValue.
+func encodeGeneric(data any) (uint32, []byte, error) {
+    var typ uint32
+    switch v := data.(type) {
+    case TypedValue:
+        if v.Raw != nil {
+            return v.Type, v.Raw, nil
+        }
+        raw, err := encodeValue(v.Type, v.Data)
+        return v.Type, raw, err
+    case string:
+        typ = registry.SZ
+    case ExpandString:
+        typ, data = registry.EXPAND_SZ, string(v)
+    case []string:
+        typ = registry.MULTI_SZ
+    case uint32:
+        typ = registry.DWORD
+    case BigEndianDWord:
+        typ, data = registry.DWORD_BIG_ENDIAN, uint32(v)
+    case uint64:
+        typ = registry.QWORD
+    case []byte:
+        typ = registry.BINARY
+    case NoneValue:
+        typ, data = registry.NONE, []byte(v)
+    default:
+        return 0, nil, fmt.Errorf("winreg: unsupported value data type %T", data)
+    }
+
+    raw, err := encodeValue(typ, data)
+    return typ, raw, err
+}
+
+// ReadDWordAs reads a DWORD value as the enum-like type T:
+//
+//    level, err := ReadDWordAs[LogLevel](root, keyPath, "LogLevel")
+func ReadDWordAs[T ~uint32](root registry.Key, keyPath, valueName string) (T, error) {
+    v, err := ReadDWordValue(root, keyPath, valueName)
+    return T(v), err
+}
+
+// WriteDWordAs writes a value of the enum-like type T as a DWORD.
+func WriteDWordAs[T ~uint32](root registry.Key, keyPath, valueName string, data T) error {
+    return WriteDWordValue(root, keyPath, valueName, uint32(data))
+}
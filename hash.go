@@ -0,0 +1,252 @@
+package winreg
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/hex"
+    "errors"
+    "hash"
+    "sort"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// HashSubtree returns a SHA-256 digest of the key at keyPath and everything
+// beneath it: the names, types and raw data of all values and the names of
+// all subkeys. Names are visited in case-insensitive order and paths are
+// hashed relative to keyPath, so two copies of a subtree at different
+// locations hash the same. Key timestamps and security are not included.
+func HashSubtree(root registry.Key, keyPath string) ([]byte, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    h := sha256.New()
+    if err := hashKey(h, k, ""); err != nil {
+        return nil, err
+    }
+    return h.Sum(nil), nil
+}
+
+// hashKey feeds the open key k, known as rel, and its subkeys into h.
+func hashKey(h hash.Hash, k registry.Key, rel string) error {
+    hashField(h, "K", []byte(rel))
+    if err := hashValues(h, k); err != nil {
+        return err
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return err
+    }
+    sortFold(subKeys)
+    for _, name := range subKeys {
+        sk, err := openKey(k, name, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+        if err != nil {
+            return err
+        }
+        err = hashKey(h, sk, joinKeyPath(rel, name))
+        sk.Close()
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// hashValues feeds the names, types and data of the values of the open key
+// k into h.
+func hashValues(h hash.Hash, k registry.Key) error {
+    names, err := k.ReadValueNames(-1)
+    if err != nil {
+        return err
+    }
+    sortFold(names)
+    for _, name := range names {
+        raw, typ, err := readRawValue(k, name)
+        if err != nil {
+            return err
+        }
+        hashField(h, "V", []byte(name))
+        hashField(h, "T", binary.LittleEndian.AppendUint32(nil, typ))
+        hashField(h, "D", raw)
+    }
+    return nil
+}
+
+// VerifySubtree reports whether the subtree at keyPath still hashes to
+// expected, a digest previously returned by HashSubtree. Use
+// HashSubtreeKeys and VerifySubtreeKeys instead to learn where a subtree
+// changed.
+func VerifySubtree(root registry.Key, keyPath string, expected []byte) (bool, error) {
+    sum, err := HashSubtree(root, keyPath)
+    if err != nil {
+        return false, err
+    }
+    return bytes.Equal(sum, expected), nil
+}
+
+// SubtreeHashes holds a SHA-256 digest of the values of every key in a
+// subtree, keyed by the key's path relative to the subtree, with "" for the
+// subtree's own key.
+type SubtreeHashes map[string][]byte
+
+// HashSubtreeKeys hashes the values of the key at keyPath and of every key
+// beneath it separately, so that VerifySubtreeKeys can later tell which key
+// changed. The same data as for HashSubtree is included.
+func HashSubtreeKeys(root registry.Key, keyPath string) (SubtreeHashes, error) {
+    hashes := make(SubtreeHashes)
+    err := walkKeyHashes(root, keyPath, func(rel string, sum []byte) error {
+        hashes[rel] = sum
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return hashes, nil
+}
+
+// VerifySubtreeKeys compares the subtree at keyPath with hashes previously
+// returned by HashSubtreeKeys. If they differ, it returns false and the path,
+// relative to root, of the first key that was added, removed or whose
+// values changed. Keys are checked parents first and siblings in
+// case-insensitive order, with removed keys reported after all others.
+func VerifySubtreeKeys(root registry.Key, keyPath string, expected SubtreeHashes) (bool, string, error) {
+    base := cleanPath(keyPath)
+
+    var diff string
+    seen := make(map[string]bool, len(expected))
+    err := walkKeyHashes(root, keyPath, func(rel string, sum []byte) error {
+        seen[rel] = true
+        if want, ok := expected[rel]; !ok || !bytes.Equal(sum, want) {
+            diff = rel
+            return errStopHashing
+        }
+        return nil
+    })
+    if err == errStopHashing {
+        return false, joinKeyPath(base, diff), nil
+    }
+    if err != nil {
+        return false, "", err
+    }
+
+    var removed []string
+    for rel := range expected {
+        if !seen[rel] {
+            removed = append(removed, rel)
+        }
+    }
+    if len(removed) > 0 {
+        sortFold(removed)
+        return false, joinKeyPath(base, removed[0]), nil
+    }
+    return true, "", nil
+}
+
+// errStopHashing ends walkKeyHashes early.
+var errStopHashing = errors.New("winreg: stop hashing")
+
+// walkKeyHashes calls fn with the hash of the values of the key at keyPath
+// and of each key beneath it, parents first and siblings in case-insensitive
+// order, passing paths relative to keyPath.
+func walkKeyHashes(root registry.Key, keyPath string, fn func(rel string, sum []byte) error) error {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return walkKeyHashesAt(k, "", fn)
+}
+
+func walkKeyHashesAt(k registry.Key, rel string, fn func(rel string, sum []byte) error) error {
+    h := sha256.New()
+    if err := hashValues(h, k); err != nil {
+        return err
+    }
+    if err := fn(rel, h.Sum(nil)); err != nil {
+        return err
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return err
+    }
+    sortFold(subKeys)
+    for _, name := range subKeys {
+        sk, err := openKey(k, name, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+        if err != nil {
+            return err
+        }
+        err = walkKeyHashesAt(sk, joinKeyPath(rel, name), fn)
+        sk.Close()
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// hashField writes a tagged, length-prefixed field so that adjacent fields
+// cannot be confused.
+func hashField(h hash.Hash, tag string, data []byte) {
+    h.Write([]byte(tag))
+    h.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(data))))
+    h.Write(data)
+}
+
+// sortFold sorts names case-insensitively, the way the registry orders them.
+func sortFold(names []string) {
+    sort.Slice(names, func(i, j int) bool {
+        return strings.ToLower(names[i]) < strings.ToLower(names[j])
+    })
+}
+
+// FindDuplicateValues walks the subtree at keyPath and groups the values by
+// their type and raw data. It returns the groups with more than one member,
+// keyed by the hex SHA-256 digest of the type and data, each listing the
+// paths, relative to root, of the keys holding such a value. A key is listed
+// once for each of its values in the group.
+func FindDuplicateValues(root registry.Key, keyPath string) (map[string][]string, error) {
+    groups := make(map[string][]string)
+    err := WalkKeys(root, keyPath, func(path string) error {
+        k, err := openKey(root, path, registry.QUERY_VALUE)
+        if err != nil {
+            return err
+        }
+        defer k.Close()
+
+        names, err := k.ReadValueNames(-1)
+        if err != nil {
+            return err
+        }
+        for _, name := range names {
+            raw, typ, err := readRawValue(k, name)
+            if err != nil {
+                return err
+            }
+            h := sha256.New()
+            hashField(h, "T", binary.LittleEndian.AppendUint32(nil, typ))
+            hashField(h, "D", raw)
+            sum := hex.EncodeToString(h.Sum(nil))
+            groups[sum] = append(groups[sum], path)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    for sum, paths := range groups {
+        if len(paths) < 2 {
+            delete(groups, sum)
+        }
+    }
+    return groups, nil
+}
@@ -0,0 +1,93 @@
+package winreg
+
+import (
+    "fmt"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// hiveListPath is the key under which the kernel lists the loaded hives.
+const hiveListPath = `SYSTEM\CurrentControlSet\Control\hivelist`
+
+// Hive describes a loaded registry hive.
+type Hive struct {
+    // RegistryPath is the NT path of the hive's root key, such as
+    // `\REGISTRY\MACHINE\SOFTWARE`.
+    RegistryPath string
+
+    // File is the NT path of the backing file, such as
+    // `\Device\HarddiskVolume3\Windows\System32\config\SOFTWARE`. It is
+    // empty for volatile hives like HARDWARE, which live in memory only.
+    File string
+
+    // Root and KeyPath locate the hive's root key for use with the other
+    // functions of this package. Root is zero if the hive is mounted
+    // somewhere other than HKEY_LOCAL_MACHINE or HKEY_USERS.
+    Root    registry.Key
+    KeyPath string
+}
+
+// ListLoadedHives returns the hives currently mounted, as listed in
+// HKLM\SYSTEM\CurrentControlSet\Control\hivelist.
+func ListLoadedHives() ([]Hive, error) {
+    k, err := openKey(registry.LOCAL_MACHINE, hiveListPath, registry.QUERY_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    names, err := k.ReadValueNames(-1)
+    if err != nil {
+        return nil, err
+    }
+
+    hives := make([]Hive, 0, len(names))
+    for _, name := range names {
+        if name == "" {
+            continue
+        }
+        file, _, err := k.GetStringValue(name)
+        if err != nil {
+            return nil, err
+        }
+
+        h := Hive{RegistryPath: name, File: file}
+        h.Root, h.KeyPath = splitNTRegistryPath(name)
+        hives = append(hives, h)
+    }
+
+    return hives, nil
+}
+
+// splitNTRegistryPath maps an NT registry path below \REGISTRY\MACHINE or
+// \REGISTRY\USER to a root key and the path beneath it. A zero root is
+// returned for other paths.
+func splitNTRegistryPath(ntPath string) (registry.Key, string) {
+    prefixes := []struct {
+        prefix string
+        root   registry.Key
+    }{
+        {`REGISTRY\MACHINE`, registry.LOCAL_MACHINE},
+        {`REGISTRY\USER`, registry.USERS},
+    }
+
+    ntPath = cleanPath(ntPath)
+    for _, p := range prefixes {
+        if hasPathPrefix(ntPath, p.prefix) {
+            return p.root, cleanPath(ntPath[len(p.prefix):])
+        }
+    }
+    return 0, ""
+}
+
+// OpenNTPath opens a key given by its NT path, as used by the kernel, ETW
+// events and KeyPath, such as `\REGISTRY\MACHINE\SOFTWARE\Microsoft` or
+// `\REGISTRY\USER\S-1-5-18\Software`. Only paths below \REGISTRY\MACHINE
+// and \REGISTRY\USER are supported; others yield an error.
+func OpenNTPath(ntPath string, access uint32) (registry.Key, error) {
+    root, keyPath := splitNTRegistryPath(ntPath)
+    if root == 0 {
+        return 0, fmt.Errorf("winreg: unsupported NT registry path %q", ntPath)
+    }
+    return openKey(root, keyPath, access)
+}
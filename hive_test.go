@@ -0,0 +1,35 @@
+package winreg
+
+import (
+    "testing"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+func TestSplitNTRegistryPath(t *testing.T) {
+    tests := []struct {
+        in      string
+        root    registry.Key
+        keyPath string
+    }{
+        {`\REGISTRY\MACHINE\SOFTWARE\X`, registry.LOCAL_MACHINE, `SOFTWARE\X`},
+        {`\registry\machine\SOFTWARE`, registry.LOCAL_MACHINE, `SOFTWARE`},
+        {`\REGISTRY\MACHINE`, registry.LOCAL_MACHINE, ``},
+        {`\REGISTRY\MACHINE\`, registry.LOCAL_MACHINE, ``},
+        {`REGISTRY\MACHINE`, registry.LOCAL_MACHINE, ``},
+        {`\\REGISTRY\MACHINE\SOFTWARE\X`, registry.LOCAL_MACHINE, `SOFTWARE\X`},
+        {`\REGISTRY\\MACHINE\SOFTWARE`, registry.LOCAL_MACHINE, `SOFTWARE`},
+        {`\REGISTRY\MACHINE\\SOFTWARE\\X\`, registry.LOCAL_MACHINE, `SOFTWARE\X`},
+        {`\REGISTRY\USER\S-1-5-18\Software`, registry.USERS, `S-1-5-18\Software`},
+        {`\REGISTRY\MACHINEX\SOFTWARE`, 0, ``},
+        {`\REGISTRY\A`, 0, ``},
+        {`\REGISTRY`, 0, ``},
+        {``, 0, ``},
+    }
+    for _, tt := range tests {
+        root, keyPath := splitNTRegistryPath(tt.in)
+        if root != tt.root || keyPath != tt.keyPath {
+            t.Errorf("splitNTRegistryPath(%q) = %v, %q; want %v, %q", tt.in, root, keyPath, tt.root, tt.keyPath)
+        }
+    }
+}
@@ -0,0 +1,77 @@
+package winreg
+
+import (
+    "bytes"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// WriteDWordValueIfChanged writes a DWORD value only if the value does not
+// already hold exactly that data, sparing the registry a write and the
+// change notifications that come with it. changed reports whether a write
+// took place. A value of another type is always overwritten.
+func WriteDWordValueIfChanged(root registry.Key, keyPath, valueName string, data uint32) (changed bool, err error) {
+    return writeIfChanged("WriteDWordValueIfChanged", root, keyPath, valueName, registry.DWORD, data)
+}
+
+// WriteStringValueIfChanged is WriteDWordValueIfChanged for SZ values.
+func WriteStringValueIfChanged(root registry.Key, keyPath, valueName, data string) (changed bool, err error) {
+    return writeIfChanged("WriteStringValueIfChanged", root, keyPath, valueName, registry.SZ, data)
+}
+
+// WriteBinaryValueIfChanged is WriteDWordValueIfChanged for BINARY values.
+func WriteBinaryValueIfChanged(root registry.Key, keyPath, valueName string, data []byte) (changed bool, err error) {
+    return writeIfChanged("WriteBinaryValueIfChanged", root, keyPath, valueName, registry.BINARY, data)
+}
+
+// writeIfChanged writes data as type typ unless the value already has that
+// type and the same raw bytes.
+func writeIfChanged(op string, root registry.Key, keyPath, valueName string, typ uint32, data any) (bool, error) {
+    raw, err := encodeValue(typ, data)
+    if err != nil {
+        return false, err
+    }
+
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return false, err
+    }
+    defer k.Close()
+
+    cur, curType, err := readRawValue(k, valueName)
+    if err == nil && curType == typ && bytes.Equal(cur, raw) {
+        return false, nil
+    }
+    if err != nil && err != registry.ErrNotExist {
+        return false, err
+    }
+
+    if err := setAudited(op, root, keyPath, k, valueName, typ, raw, data); err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// setAudited writes raw to the open key k, reporting the write to the audit
+// sink as op.
+func setAudited(op string, root registry.Key, keyPath string, k registry.Key, valueName string, typ uint32, raw []byte, data any) (err error) {
+    defer auditValue(op, root, keyPath, valueName, data)(&err)
+
+    return regSetValueEx(k, valueName, typ, raw)
+}
+
+// deleteValueAudited deletes valueName from the open key k, reporting the
+// deletion to the audit sink as op.
+func deleteValueAudited(op string, root registry.Key, keyPath string, k registry.Key, valueName string) (err error) {
+    defer auditValue(op, root, keyPath, valueName, nil)(&err)
+
+    return k.DeleteValue(valueName)
+}
+
+// createKeyAudited is createKey, reporting the operation to the audit sink
+// as op.
+func createKeyAudited(op string, root registry.Key, keyPath string, access uint32) (k registry.Key, err error) {
+    defer auditKey(op, root, keyPath)(&err)
+
+    return createKey(root, keyPath, 0, access)
+}
@@ -0,0 +1,52 @@
+package winreg
+
+import (
+    "runtime"
+    "sync"
+
+    "golang.org/x/sys/windows"
+)
+
+var (
+    disableCacheOnce sync.Once
+    disableCacheErr  error
+)
+
+// WithImpersonation runs fn while the calling thread impersonates token,
+// typically the token of a logged-on user obtained by a service. Inside fn,
+// registry.CURRENT_USER refers to that user's hive, so the ordinary HKCU
+// helpers of this package act on the user's settings.
+//
+// Impersonation applies to one OS thread: fn must do its registry work on
+// the calling goroutine rather than hand it to other goroutines. The
+// impersonation is reverted when fn returns or panics. To make
+// HKEY_CURRENT_USER follow the impersonated user, the process-wide cache of
+// predefined key handles is disabled on first use.
+func WithImpersonation(token windows.Token, fn func() error) (err error) {
+    disableCacheOnce.Do(func() {
+        disableCacheErr = regDisablePredefinedCacheEx()
+    })
+    if disableCacheErr != nil {
+        return disableCacheErr
+    }
+
+    runtime.LockOSThread()
+    if err := impersonateLoggedOnUser(token); err != nil {
+        runtime.UnlockOSThread()
+        return err
+    }
+    defer func() {
+        if rerr := windows.RevertToSelf(); rerr != nil {
+            // The thread still carries the user's token. Keep it locked so
+            // that the runtime discards it when the goroutine exits instead
+            // of reusing it.
+            if err == nil {
+                err = rerr
+            }
+            return
+        }
+        runtime.UnlockOSThread()
+    }()
+
+    return fn()
+}
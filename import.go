@@ -0,0 +1,532 @@
+package winreg
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// errNotRegFile is returned when the input lacks a .reg file header.
+var errNotRegFile = errors.New("winreg: missing .reg file header")
+
+// ImportOptions controls ImportFromRegFile, ImportFromJSON and
+// ImportFromYAML.
+type ImportOptions struct {
+    // ContinueOnError makes the import skip entries that cannot be applied
+    // instead of stopping at the first one. The failures are returned
+    // together as ImportErrors once the input has been read to the end.
+    // Malformed input that cannot be parsed any further and read errors
+    // still stop the import.
+    ContinueOnError bool
+}
+
+// ImportFailure describes a key or value that could not be imported. Path is
+// the key path as written in the input and ValueName is empty for failures
+// that concern the key itself.
+type ImportFailure struct {
+    Path      string
+    ValueName string
+    Err       error
+}
+
+func (f *ImportFailure) Error() string {
+    if f.ValueName == "" {
+        return fmt.Sprintf("winreg: import of %s: %v", f.Path, f.Err)
+    }
+    return fmt.Sprintf("winreg: import of %s value %q: %v", f.Path, f.ValueName, f.Err)
+}
+
+func (f *ImportFailure) Unwrap() error {
+    return f.Err
+}
+
+// ImportErrors is returned by the importers in ContinueOnError mode and
+// lists every entry that was skipped, in input order.
+type ImportErrors []*ImportFailure
+
+func (e ImportErrors) Error() string {
+    if len(e) == 1 {
+        return e[0].Error()
+    }
+    return fmt.Sprintf("%v (and %d more import failures)", e[0], len(e)-1)
+}
+
+// importer collects failures according to the import options.
+type importer struct {
+    opts     ImportOptions
+    failures ImportErrors
+}
+
+// fail records a failed entry. It returns a non-nil error if the import has
+// to stop.
+func (im *importer) fail(path, valueName string, err error) error {
+    f := &ImportFailure{Path: path, ValueName: valueName, Err: err}
+    if !im.opts.ContinueOnError {
+        return f
+    }
+    im.failures = append(im.failures, f)
+    return nil
+}
+
+func (im *importer) result() error {
+    if len(im.failures) > 0 {
+        return im.failures
+    }
+    return nil
+}
+
+// ImportFromRegFile applies a file in the format written by regedit and
+// ExportToRegFile, either UTF-16LE with a byte order mark or UTF-8. Keys are
+// created and values written as they are read, in a single pass over r, so
+// the file is never held in memory. Key deletions ([-KEY]) and value
+// deletions ("name"=-) are honoured.
+//
+// Files with the older REGEDIT4 header are accepted too. Their hex(2) and
+// hex(7) data is in the ANSI code page rather than UTF-16LE and is converted.
+//
+// Without ContinueOnError the import stops at the first entry that cannot be
+// applied; what was written up to that point stays written.
+func ImportFromRegFile(r io.Reader, opts ImportOptions) error {
+    rr := newRegFileReader(r)
+    header, err := rr.ReadLine()
+    if err != nil {
+        return err
+    }
+    header = strings.TrimSpace(header)
+    if header != "Windows Registry Editor Version 5.00" && header != "REGEDIT4" {
+        return errNotRegFile
+    }
+    ansi := header == "REGEDIT4"
+
+    im := &importer{opts: opts}
+
+    // k is the key of the current section. It is zero before the first
+    // section, after a deletion and after a section that failed; values
+    // are skipped then.
+    var (
+        k       registry.Key
+        section string
+    )
+    defer func() {
+        if k != 0 {
+            k.Close()
+        }
+    }()
+
+    for {
+        line, err := rr.ReadLine()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return err
+        }
+        for strings.HasSuffix(line, `\`) {
+            next, err := rr.ReadLine()
+            if err != nil && err != io.EOF {
+                return err
+            }
+            line = line[:len(line)-1] + next
+            if err == io.EOF {
+                break
+            }
+        }
+
+        line = strings.TrimSpace(line)
+        switch {
+        case line == "" || strings.HasPrefix(line, ";"):
+            continue
+
+        case strings.HasPrefix(line, "["):
+            if k != 0 {
+                k.Close()
+                k = 0
+            }
+            section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+            if err := importRegFileKey(section, &k); err != nil {
+                if err := im.fail(section, "", err); err != nil {
+                    return err
+                }
+            }
+
+        default:
+            name, data, err := splitRegFileValueLine(line)
+            if err == nil && k == 0 {
+                if section == "" {
+                    err = errors.New("value outside of a key")
+                } else {
+                    continue
+                }
+            }
+            if err == nil {
+                root, keyPath, _ := splitRegFilePath(section)
+                err = importRegFileValue(root, keyPath, k, name, data, ansi)
+            }
+            if err != nil {
+                if err := im.fail(section, name, err); err != nil {
+                    return err
+                }
+            }
+        }
+    }
+
+    return im.result()
+}
+
+// importRegFileKey creates the key named by a .reg section header and
+// stores it in k, or deletes the key tree if the name starts with '-'.
+func importRegFileKey(name string, k *registry.Key) (err error) {
+    name, remove := strings.CutPrefix(name, "-")
+    root, keyPath, err := splitRegFilePath(name)
+    if err != nil {
+        return err
+    }
+
+    if !remove {
+        *k, err = createKeyAudited("ImportFromRegFile", root, keyPath, registry.SET_VALUE)
+        return err
+    }
+
+    defer auditKey("ImportFromRegFile", root, keyPath)(&err)
+    err = deleteKeyTreeAt(root, keyPath)
+    if err == registry.ErrNotExist {
+        return nil
+    }
+    return err
+}
+
+// splitRegFilePath splits a full path such as `HKEY_CURRENT_USER\Software`
+// into its root key and the path beneath it.
+func splitRegFilePath(fullPath string) (registry.Key, string, error) {
+    rootName, keyPath, _ := strings.Cut(fullPath, `\`)
+    keyPath = cleanPath(keyPath)
+    for root, name := range rootKeyNames {
+        if strings.EqualFold(name, rootName) {
+            if keyPath == "" {
+                return 0, "", fmt.Errorf("winreg: cannot import into root key %s", name)
+            }
+            return root, keyPath, nil
+        }
+    }
+    return 0, "", errUnknownRoot
+}
+
+// splitRegFileValueLine splits a .reg value line into the value name and
+// the data after the '='. The default value is written as @.
+func splitRegFileValueLine(line string) (name, data string, err error) {
+    rest, ok := strings.CutPrefix(line, "@")
+    if !ok {
+        name, rest, ok = unquoteRegFileString(line)
+        if !ok {
+            return "", "", fmt.Errorf("winreg: malformed value line %q", line)
+        }
+    }
+    data, ok = strings.CutPrefix(strings.TrimSpace(rest), "=")
+    if !ok {
+        return "", "", fmt.Errorf("winreg: malformed value line %q", line)
+    }
+    return name, strings.TrimSpace(data), nil
+}
+
+// importRegFileValue writes or, for data "-", deletes a single value of the
+// open key k, known as keyPath under root. ansi tells that the file is a
+// REGEDIT4 file, whose hex(2) and hex(7) strings are in the ANSI code page.
+func importRegFileValue(root registry.Key, keyPath string, k registry.Key, name, data string, ansi bool) error {
+    if data == "-" {
+        err := deleteValueAudited("ImportFromRegFile", root, keyPath, k, name)
+        if err == registry.ErrNotExist {
+            return nil
+        }
+        return err
+    }
+
+    typ, raw, err := ParseRegFileValue(data)
+    if err != nil {
+        return err
+    }
+    if ansi && (typ == registry.EXPAND_SZ || typ == registry.MULTI_SZ) {
+        if raw, err = ansiToUTF16(raw); err != nil {
+            return err
+        }
+    }
+    return setAudited("ImportFromRegFile", root, keyPath, k, name, typ, raw, decodeValue(typ, raw))
+}
+
+// ImportFromJSON applies a document in the format written by ExportToJSON,
+// creating the key at keyPath and everything beneath it. The document is
+// decoded token by token and each value is written as soon as it has been
+// read, so the document is never held in memory.
+//
+// Without ContinueOnError the import stops at the first key or value that
+// cannot be applied; what was written up to that point stays written.
+func ImportFromJSON(root registry.Key, keyPath string, r io.Reader, opts ImportOptions) error {
+    im := &importer{opts: opts}
+    if err := importJSONKey(json.NewDecoder(r), im, root, cleanPath(keyPath)); err != nil {
+        return err
+    }
+    return im.result()
+}
+
+// importJSONKey imports the key object at the decoder's position.
+func importJSONKey(dec *json.Decoder, im *importer, root registry.Key, keyPath string) error {
+    if err := expectJSONDelim(dec, '{'); err != nil {
+        return err
+    }
+
+    // A key that cannot be created is recorded once; its values are
+    // skipped but the subkeys are still attempted.
+    k, err := createKeyAudited("ImportFromJSON", root, keyPath, registry.SET_VALUE)
+    if err != nil {
+        if err := im.fail(keyPath, "", err); err != nil {
+            return err
+        }
+        k = 0
+    } else {
+        defer k.Close()
+    }
+
+    for dec.More() {
+        tok, err := dec.Token()
+        if err != nil {
+            return err
+        }
+        switch tok {
+        case "values":
+            err = importJSONValues(dec, im, root, keyPath, k)
+        case "subkeys":
+            err = importJSONSubKeys(dec, im, root, keyPath)
+        default:
+            var skip json.RawMessage
+            err = dec.Decode(&skip)
+        }
+        if err != nil {
+            return err
+        }
+    }
+
+    return expectJSONDelim(dec, '}')
+}
+
+// importJSONValues imports the "values" object at the decoder's position
+// into k, known as keyPath under root. If k is zero the values are read and
+// discarded.
+func importJSONValues(dec *json.Decoder, im *importer, root registry.Key, keyPath string, k registry.Key) error {
+    if err := expectJSONDelim(dec, '{'); err != nil {
+        return err
+    }
+
+    for dec.More() {
+        tok, err := dec.Token()
+        if err != nil {
+            return err
+        }
+        name, _ := tok.(string)
+
+        var v struct {
+            Type string          `json:"type"`
+            Data json.RawMessage `json:"data"`
+        }
+        err = dec.Decode(&v)
+        var typeErr *json.UnmarshalTypeError
+        if err != nil && !errors.As(err, &typeErr) {
+            return err
+        }
+        if k == 0 {
+            continue
+        }
+
+        if err == nil {
+            var typ uint32
+            var raw []byte
+            if typ, raw, err = decodeJSONValue(v.Type, v.Data); err == nil {
+                err = setAudited("ImportFromJSON", root, keyPath, k, name, typ, raw, decodeValue(typ, raw))
+            }
+        }
+        if err != nil {
+            if err := im.fail(keyPath, name, err); err != nil {
+                return err
+            }
+        }
+    }
+
+    return expectJSONDelim(dec, '}')
+}
+
+// importJSONSubKeys imports the "subkeys" object at the decoder's position.
+func importJSONSubKeys(dec *json.Decoder, im *importer, root registry.Key, keyPath string) error {
+    if err := expectJSONDelim(dec, '{'); err != nil {
+        return err
+    }
+
+    for dec.More() {
+        tok, err := dec.Token()
+        if err != nil {
+            return err
+        }
+        name, _ := tok.(string)
+        if err := importJSONKey(dec, im, root, joinKeyPath(keyPath, name)); err != nil {
+            return err
+        }
+    }
+
+    return expectJSONDelim(dec, '}')
+}
+
+// ImportFromYAML applies a document in the format written by ExportToYAML,
+// creating the key at keyPath and everything beneath it. Hand-edited
+// documents may use plain or single-quoted scalars, comments and flow
+// sequences such as [a, b]; as in YAML, unquoted digits are read as numbers,
+// so strings that look like one must be quoted. Anchors, tags and block
+// scalars are not supported. Unlike ImportFromJSON, the document is read in
+// full before anything is written, so a syntax error leaves the registry
+// untouched.
+//
+// Without ContinueOnError the import stops at the first key or value that
+// cannot be applied; what was written up to that point stays written.
+func ImportFromYAML(root registry.Key, keyPath string, r io.Reader, opts ImportOptions) error {
+    doc, err := parseYAML(r)
+    if err != nil {
+        return err
+    }
+
+    im := &importer{opts: opts}
+    if err := importYAMLKey(doc, im, root, cleanPath(keyPath)); err != nil {
+        return err
+    }
+    return im.result()
+}
+
+// importYAMLKey imports the key mapping n. An empty entry is an empty key.
+func importYAMLKey(n *yamlNode, im *importer, root registry.Key, keyPath string) error {
+    if n.kind != yamlMapping && !n.isNull() {
+        return yamlError(n.line, "a key must be a mapping")
+    }
+
+    // A key that cannot be created is recorded once; its values are
+    // skipped but the subkeys are still attempted.
+    k, err := createKeyAudited("ImportFromYAML", root, keyPath, registry.SET_VALUE)
+    if err != nil {
+        if err := im.fail(keyPath, "", err); err != nil {
+            return err
+        }
+        k = 0
+    } else {
+        defer k.Close()
+    }
+
+    if values := n.field("values"); values != nil && k != 0 {
+        if err := importYAMLValues(values, im, root, keyPath, k); err != nil {
+            return err
+        }
+    }
+
+    subKeys := n.field("subkeys")
+    if subKeys == nil || subKeys.isNull() {
+        return nil
+    }
+    if subKeys.kind != yamlMapping {
+        return yamlError(subKeys.line, "subkeys must be a mapping")
+    }
+    for _, name := range subKeys.keys {
+        if err := importYAMLKey(subKeys.fields[name], im, root, joinKeyPath(keyPath, name)); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// importYAMLValues imports the "values" mapping n into k, known as keyPath
+// under root. The type and data of each value are converted to JSON and
+// decoded with decodeJSONValue, so both formats accept exactly the same data.
+func importYAMLValues(n *yamlNode, im *importer, root registry.Key, keyPath string, k registry.Key) error {
+    if n.isNull() {
+        return nil
+    }
+    if n.kind != yamlMapping {
+        return yamlError(n.line, "values must be a mapping")
+    }
+
+    for _, name := range n.keys {
+        v := n.fields[name]
+
+        var err error
+        typeNode, data := v.field("type"), v.field("data")
+        if typeNode == nil || data == nil {
+            err = yamlError(v.line, "a value needs a type and data")
+        } else {
+            var typeName string
+            if typeName, err = unmarshalJSONAs[string](typeNode.toJSON()); err == nil {
+                var typ uint32
+                var raw []byte
+                if typ, raw, err = decodeJSONValue(typeName, data.toJSON()); err == nil {
+                    err = setAudited("ImportFromYAML", root, keyPath, k, name, typ, raw, decodeValue(typ, raw))
+                }
+            }
+        }
+        if err != nil {
+            if err := im.fail(keyPath, name, err); err != nil {
+                return err
+            }
+        }
+    }
+
+    return nil
+}
+
+// expectJSONDelim reads the next token and checks that it is delim.
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+    tok, err := dec.Token()
+    if err != nil {
+        return err
+    }
+    if tok != delim {
+        return fmt.Errorf("winreg: unexpected JSON token %v, want %v", tok, delim)
+    }
+    return nil
+}
+
+// decodeJSONValue is the inverse of jsonValue: it converts the type name
+// and data of a JSON value back to the registry type and raw bytes.
+func decodeJSONValue(typeName string, data json.RawMessage) (uint32, []byte, error) {
+    typ, ok := parseTypeName(typeName)
+    if !ok {
+        return 0, nil, fmt.Errorf("winreg: unknown value type %q", typeName)
+    }
+
+    // Integer data of a malformed size is exported as base64, like the
+    // types without a JSON form of their own.
+    isString := len(data) > 0 && data[0] == '"'
+
+    var v any
+    var err error
+    switch {
+    case typ == registry.SZ || typ == registry.EXPAND_SZ || typ == registry.LINK:
+        v, err = unmarshalJSONAs[string](data)
+    case typ == registry.MULTI_SZ:
+        v, err = unmarshalJSONAs[[]string](data)
+    case (typ == registry.DWORD || typ == registry.DWORD_BIG_ENDIAN) && !isString:
+        v, err = unmarshalJSONAs[uint32](data)
+    case typ == registry.QWORD && !isString:
+        v, err = unmarshalJSONAs[uint64](data)
+    default:
+        b, err := unmarshalJSONAs[[]byte](data)
+        return typ, b, err
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+
+    raw, err := encodeValue(typ, v)
+    return typ, raw, err
+}
+
+func unmarshalJSONAs[T any](data []byte) (T, error) {
+    var v T
+    err := json.Unmarshal(data, &v)
+    return v, err
+}
@@ -0,0 +1,59 @@
+package winreg
+
+import (
+    "golang.org/x/sys/windows/registry"
+)
+
+// symbolicLinkValue is the value of a link key that holds the NT path of the
+// link target.
+const symbolicLinkValue = "SymbolicLinkValue"
+
+// IsSymbolicLink reports whether the key at keyPath is a registry symbolic
+// link, such as HKLM\SYSTEM\CurrentControlSet. The key is opened without
+// following the link and checked for a LINK typed SymbolicLinkValue.
+// Traversals can use it to avoid walking the same subtree twice.
+func IsSymbolicLink(root registry.Key, keyPath string) (bool, error) {
+    k, err := openKeyLink(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return false, err
+    }
+    defer k.Close()
+
+    return isLinkKey(k)
+}
+
+// isLinkKey reports whether k, opened with openKeyLink and QUERY_VALUE
+// access, is a symbolic link key.
+func isLinkKey(k registry.Key) (bool, error) {
+    _, typ, err := k.GetValue(symbolicLinkValue, nil)
+    if err == registry.ErrNotExist {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    return typ == registry.LINK, nil
+}
+
+// ReadLinkValue returns the target of the symbolic link key at keyPath
+// without following it. The target is an NT path such as
+// `\REGISTRY\MACHINE\SYSTEM\ControlSet001`. registry.ErrUnexpectedType is
+// returned if the key has a SymbolicLinkValue that is not of type LINK.
+func ReadLinkValue(root registry.Key, keyPath string) (string, error) {
+    k, err := openKeyLink(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return "", err
+    }
+    defer k.Close()
+
+    raw, typ, err := readRawValue(k, symbolicLinkValue)
+    if err != nil {
+        return "", err
+    }
+    if typ != registry.LINK {
+        return "", registry.ErrUnexpectedType
+    }
+
+    return decodeString(raw), nil
+}
@@ -0,0 +1,92 @@
+package winreg
+
+import (
+    "time"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// KeyLastWriteTime returns when the key at keyPath, or one of its values,
+// was last written. Creating or deleting a direct subkey also updates it.
+func KeyLastWriteTime(root registry.Key, keyPath string) (time.Time, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return time.Time{}, err
+    }
+    defer k.Close()
+
+    info, err := k.Stat()
+    if err != nil {
+        return time.Time{}, err
+    }
+    return info.ModTime(), nil
+}
+
+// FindKeysModifiedAfter walks the subtree at keyPath and returns the paths,
+// relative to root, of the keys whose last write time is after since. Each
+// key is judged by its own timestamp, so a key shows up when its values
+// changed or a direct subkey was added or removed, while changes deeper
+// down only mark the keys where they happened.
+func FindKeysModifiedAfter(root registry.Key, keyPath string, since time.Time) ([]string, error) {
+    var paths []string
+    err := WalkKeys(root, keyPath, func(path string) error {
+        t, err := KeyLastWriteTime(root, path)
+        if err != nil {
+            return err
+        }
+        if t.After(since) {
+            paths = append(paths, path)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return paths, nil
+}
+
+// KeySummary is an overview of a subkey, as returned by SubKeySummary.
+type KeySummary struct {
+    Name          string
+    SubKeyCount   uint32
+    ValueCount    uint32
+    LastWriteTime time.Time
+}
+
+// SubKeySummary returns the size and last write time of each direct subkey
+// of the key at keyPath, in enumeration order. Each child is opened once to
+// query its information; its values and subkeys are not read.
+func SubKeySummary(root registry.Key, keyPath string) ([]KeySummary, error) {
+    k, err := openKey(root, keyPath, registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    names, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return nil, err
+    }
+
+    summaries := make([]KeySummary, 0, len(names))
+    for _, name := range names {
+        sk, err := openKey(k, name, registry.QUERY_VALUE)
+        if err != nil {
+            return nil, err
+        }
+        info, err := sk.Stat()
+        sk.Close()
+        if err != nil {
+            return nil, err
+        }
+        summaries = append(summaries, KeySummary{
+            Name:          name,
+            SubKeyCount:   info.SubKeyCount,
+            ValueCount:    info.ValueCount,
+            LastWriteTime: info.ModTime(),
+        })
+    }
+
+    return summaries, nil
+}
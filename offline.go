@@ -0,0 +1,149 @@
+package winreg
+
+import (
+    "errors"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// OfflineHive is a hive file mounted with LoadHive. Its methods mirror the
+// package's top-level functions with paths relative to the hive's root key.
+// Close unmounts the hive; every key opened beneath it must be closed first.
+type OfflineHive struct {
+    root registry.Key
+    name string
+}
+
+// LoadHive mounts the hive file, such as a user's NTUSER.DAT or an offline
+// SOFTWARE hive, as the subkey name of root, which must be
+// registry.LOCAL_MACHINE or registry.USERS. The backup and restore
+// privileges this requires are enabled in the process token, so the caller
+// must be an administrator. Defer Close to unmount the hive again.
+func LoadHive(root registry.Key, name, file string) (h *OfflineHive, err error) {
+    defer auditKey("LoadHive", root, name)(&err)
+
+    if root != registry.LOCAL_MACHINE && root != registry.USERS {
+        return nil, errors.New("winreg: hives can only be loaded under HKEY_LOCAL_MACHINE or HKEY_USERS")
+    }
+    if err := enablePrivileges("SeBackupPrivilege", "SeRestorePrivilege"); err != nil {
+        return nil, err
+    }
+    if err := regLoadKey(root, name, file); err != nil {
+        return nil, err
+    }
+
+    return &OfflineHive{root: root, name: name}, nil
+}
+
+// Close unmounts the hive. Changes have been written to the file already.
+func (h *OfflineHive) Close() (err error) {
+    defer auditKey("UnloadHive", h.root, h.name)(&err)
+
+    return regUnLoadKey(h.root, h.name)
+}
+
+// Root returns the key the hive is mounted under.
+func (h *OfflineHive) Root() registry.Key {
+    return h.root
+}
+
+// Path returns the path, relative to Root, of keyPath within the hive.
+func (h *OfflineHive) Path(keyPath string) string {
+    return joinKeyPath(h.name, cleanPath(keyPath))
+}
+
+// ReadDWordValue is ReadDWordValue within the hive.
+func (h *OfflineHive) ReadDWordValue(keyPath, valueName string) (uint32, error) {
+    return ReadDWordValue(h.root, h.Path(keyPath), valueName)
+}
+
+// WriteDWordValue is WriteDWordValue within the hive.
+func (h *OfflineHive) WriteDWordValue(keyPath, valueName string, data uint32) error {
+    return WriteDWordValue(h.root, h.Path(keyPath), valueName, data)
+}
+
+// ReadQWordValue is ReadQWordValue within the hive.
+func (h *OfflineHive) ReadQWordValue(keyPath, valueName string) (uint64, error) {
+    return ReadQWordValue(h.root, h.Path(keyPath), valueName)
+}
+
+// WriteQWordValue is WriteQWordValue within the hive.
+func (h *OfflineHive) WriteQWordValue(keyPath, valueName string, data uint64) error {
+    return WriteQWordValue(h.root, h.Path(keyPath), valueName, data)
+}
+
+// ReadStringValue is ReadStringValue within the hive.
+func (h *OfflineHive) ReadStringValue(keyPath, valueName string) (string, error) {
+    return ReadStringValue(h.root, h.Path(keyPath), valueName)
+}
+
+// ReadExpandStringValue is ReadExpandStringValue within the hive.
+func (h *OfflineHive) ReadExpandStringValue(keyPath, valueName string) (string, error) {
+    return ReadExpandStringValue(h.root, h.Path(keyPath), valueName)
+}
+
+// WriteExpandStringValue is WriteExpandStringValue within the hive.
+func (h *OfflineHive) WriteExpandStringValue(keyPath, valueName, data string) error {
+    return WriteExpandStringValue(h.root, h.Path(keyPath), valueName, data)
+}
+
+// ReadMultiStringValue is ReadMultiStringValue within the hive.
+func (h *OfflineHive) ReadMultiStringValue(keyPath, valueName string) ([]string, error) {
+    return ReadMultiStringValue(h.root, h.Path(keyPath), valueName)
+}
+
+// WriteMultiStringValue is WriteMultiStringValue within the hive.
+func (h *OfflineHive) WriteMultiStringValue(keyPath, valueName string, data []string) error {
+    return WriteMultiStringValue(h.root, h.Path(keyPath), valueName, data)
+}
+
+// ReadBinaryValue is ReadBinaryValue within the hive.
+func (h *OfflineHive) ReadBinaryValue(keyPath, valueName string) ([]byte, error) {
+    return ReadBinaryValue(h.root, h.Path(keyPath), valueName)
+}
+
+// WriteBinaryValue is WriteBinaryValue within the hive.
+func (h *OfflineHive) WriteBinaryValue(keyPath, valueName string, data []byte) error {
+    return WriteBinaryValue(h.root, h.Path(keyPath), valueName, data)
+}
+
+// ReadValue is ReadValue within the hive.
+func (h *OfflineHive) ReadValue(keyPath, valueName string) (any, error) {
+    return ReadValue(h.root, h.Path(keyPath), valueName)
+}
+
+// WriteValue is WriteValue within the hive.
+func (h *OfflineHive) WriteValue(keyPath, valueName string, data any) error {
+    return WriteValue(h.root, h.Path(keyPath), valueName, data)
+}
+
+// DeleteValue is DeleteValue within the hive.
+func (h *OfflineHive) DeleteValue(keyPath, valueName string) error {
+    return DeleteValue(h.root, h.Path(keyPath), valueName)
+}
+
+// KeyExists is KeyExists within the hive.
+func (h *OfflineHive) KeyExists(keyPath string) bool {
+    return KeyExists(h.root, h.Path(keyPath))
+}
+
+// ValueExists is ValueExists within the hive.
+func (h *OfflineHive) ValueExists(keyPath, valueName string) bool {
+    return ValueExists(h.root, h.Path(keyPath), valueName)
+}
+
+// EnumerateSubKeys is EnumerateSubKeys within the hive.
+func (h *OfflineHive) EnumerateSubKeys(keyPath string) ([]string, error) {
+    return EnumerateSubKeys(h.root, h.Path(keyPath))
+}
+
+// EnumerateValues is EnumerateValues within the hive.
+func (h *OfflineHive) EnumerateValues(keyPath string) ([]string, error) {
+    return EnumerateValues(h.root, h.Path(keyPath))
+}
+
+// CreateKey is CreateKey within the hive. The returned key must be closed
+// before the hive is.
+func (h *OfflineHive) CreateKey(keyPath string) (registry.Key, error) {
+    return CreateKey(h.root, h.Path(keyPath))
+}
@@ -0,0 +1,210 @@
+package winreg
+
+import (
+    "cmp"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// ReadStringAs reads a string value and converts it with parse. Surrounding
+// whitespace is trimmed first. A parse failure is reported with the value
+// name and the offending data.
+func ReadStringAs[T any](root registry.Key, keyPath, valueName string, parse func(string) (T, error)) (T, error) {
+    var zero T
+
+    s, err := ReadStringValue(root, keyPath, valueName)
+    if err != nil {
+        return zero, err
+    }
+
+    v, err := parse(strings.TrimSpace(s))
+    if err != nil {
+        return zero, fmt.Errorf("winreg: malformed data %q in value %q: %w", s, valueName, err)
+    }
+
+    return v, nil
+}
+
+// ReadStringAsInt reads a string value holding an integer. Decimal as well as
+// 0x-prefixed hexadecimal data is accepted. Leading zeros do not make the
+// data octal, so "010" is 10.
+func ReadStringAsInt(root registry.Key, keyPath, valueName string) (int64, error) {
+    return ReadStringAs(root, keyPath, valueName, parseRegistryInt)
+}
+
+// parseRegistryInt parses a decimal or 0x-prefixed hexadecimal integer with
+// an optional sign.
+func parseRegistryInt(s string) (int64, error) {
+    sign, digits := "", s
+    if s != "" && (s[0] == '+' || s[0] == '-') {
+        sign, digits = s[:1], s[1:]
+    }
+    if len(digits) > 2 && digits[0] == '0' && (digits[1] == 'x' || digits[1] == 'X') {
+        hex := digits[2:]
+        if hex[0] == '+' || hex[0] == '-' {
+            return 0, strconv.ErrSyntax
+        }
+        return strconv.ParseInt(sign+hex, 16, 64)
+    }
+    return strconv.ParseInt(s, 10, 64)
+}
+
+// parseRegistryUint is parseRegistryInt for unsigned numbers, which cover
+// the full range of a QWORD. Signs are not accepted.
+func parseRegistryUint(s string) (uint64, error) {
+    if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+        return strconv.ParseUint(s[2:], 16, 64)
+    }
+    return strconv.ParseUint(s, 10, 64)
+}
+
+// ReadStringAsDuration reads a string value holding a duration such as "1m30s",
+// as accepted by time.ParseDuration.
+func ReadStringAsDuration(root registry.Key, keyPath, valueName string) (time.Duration, error) {
+    return ReadStringAs(root, keyPath, valueName, time.ParseDuration)
+}
+
+// ReadStringAsBool reads a string value holding a boolean, as accepted by
+// strconv.ParseBool ("1", "true", "0", "false" and so on).
+func ReadStringAsBool(root registry.Key, keyPath, valueName string) (bool, error) {
+    return ReadStringAs(root, keyPath, valueName, strconv.ParseBool)
+}
+
+// ReadVersionValue reads a string value holding a dotted version number
+// such as "10.0.19041.1" and returns its numeric components. Anything after
+// the numeric part is ignored, so "6.3.9600 (winblue_rtm)" and "2.1.0-beta"
+// yield [6 3 9600] and [2 1 0].
+func ReadVersionValue(root registry.Key, keyPath, valueName string) ([]int, error) {
+    return ReadStringAs(root, keyPath, valueName, parseVersion)
+}
+
+func parseVersion(s string) ([]int, error) {
+    var v []int
+    for _, part := range strings.Split(s, ".") {
+        digits := len(part) - len(strings.TrimLeft(part, "0123456789"))
+        if digits == 0 {
+            break
+        }
+        n, err := strconv.Atoi(part[:digits])
+        if err != nil {
+            return nil, err
+        }
+        v = append(v, n)
+        if digits < len(part) {
+            break
+        }
+    }
+    if v == nil {
+        return nil, errors.New("not a version number")
+    }
+    return v, nil
+}
+
+// CompareVersions compares two versions as returned by ReadVersionValue
+// component by component and returns -1, 0 or +1 if a is lower than, equal
+// to or higher than b. Missing trailing components count as zero, so 1.2
+// and 1.2.0 are equal.
+func CompareVersions(a, b []int) int {
+    for i := 0; i < len(a) || i < len(b); i++ {
+        var x, y int
+        if i < len(a) {
+            x = a[i]
+        }
+        if i < len(b) {
+            y = b[i]
+        }
+        if c := cmp.Compare(x, y); c != 0 {
+            return c
+        }
+    }
+    return 0
+}
+
+// ReadGUIDValue reads a string value holding a GUID such as a CLSID, in the
+// braced `{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}` form. The braces may be
+// omitted.
+func ReadGUIDValue(root registry.Key, keyPath, valueName string) (windows.GUID, error) {
+    return ReadStringAs(root, keyPath, valueName, func(s string) (windows.GUID, error) {
+        if !strings.HasPrefix(s, "{") {
+            s = "{" + s + "}"
+        }
+        return windows.GUIDFromString(s)
+    })
+}
+
+// WriteGUIDValue writes guid as an SZ value in the canonical braced,
+// upper-case form.
+func WriteGUIDValue(root registry.Key, keyPath, valueName string, guid windows.GUID) (err error) {
+    defer auditValue("WriteGUIDValue", root, keyPath, valueName, guid)(&err)
+
+    k, err := openKey(root, keyPath, registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return k.SetStringValue(valueName, guid.String())
+}
+
+// ReadStringListValue reads a string value holding a list joined by sep,
+// such as "a; b;c" with sep ";", and returns its items. Whitespace around
+// the items is trimmed and empty items are dropped, so an empty string
+// yields no items and a stray trailing separator is harmless.
+// This is for delimited SZ and EXPAND_SZ values; MULTI_SZ lists are read
+// with ReadMultiStringValue.
+func ReadStringListValue(root registry.Key, keyPath, valueName, sep string) ([]string, error) {
+    if sep == "" {
+        return nil, errEmptySeparator
+    }
+    return ReadStringAs(root, keyPath, valueName, func(s string) ([]string, error) {
+        var items []string
+        for _, item := range strings.Split(s, sep) {
+            if item = strings.TrimSpace(item); item != "" {
+                items = append(items, item)
+            }
+        }
+        return items, nil
+    })
+}
+
+// WriteStringListValue joins items with sep and writes them as a string
+// value, the inverse of ReadStringListValue. An existing EXPAND_SZ value
+// keeps its type; otherwise SZ is written. Items containing sep cannot be
+// read back and are rejected.
+func WriteStringListValue(root registry.Key, keyPath, valueName, sep string, items []string) error {
+    if sep == "" {
+        return errEmptySeparator
+    }
+    for _, item := range items {
+        if strings.Contains(item, sep) {
+            return fmt.Errorf("winreg: list item %q contains the separator %q", item, sep)
+        }
+    }
+    data := strings.Join(items, sep)
+
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    typ := uint32(registry.SZ)
+    if _, cur, err := k.GetValue(valueName, nil); err == nil && cur == registry.EXPAND_SZ {
+        typ = registry.EXPAND_SZ
+    }
+    raw, err := encodeValue(typ, data)
+    if err != nil {
+        return err
+    }
+
+    return setAudited("WriteStringListValue", root, keyPath, k, valueName, typ, raw, data)
+}
+
+// errEmptySeparator is returned for an empty list separator.
+var errEmptySeparator = errors.New("winreg: empty list separator")
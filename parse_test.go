@@ -0,0 +1,29 @@
+package winreg
+
+import "testing"
+
+func TestParseRegistryInt(t *testing.T) {
+    tests := []struct {
+        in   string
+        want int64
+        ok   bool
+    }{
+        {"42", 42, true},
+        {"010", 10, true},
+        {"-7", -7, true},
+        {"0x1F", 31, true},
+        {"0X1f", 31, true},
+        {"-0x10", -16, true},
+        {"0b101", 0, false},
+        {"0o17", 0, false},
+        {"1_000", 0, false},
+        {"0x-5", 0, false},
+        {"", 0, false},
+    }
+    for _, tt := range tests {
+        got, err := parseRegistryInt(tt.in)
+        if (err == nil) != tt.ok || got != tt.want {
+            t.Errorf("parseRegistryInt(%q) = %d, %v; want %d, ok %v", tt.in, got, err, tt.want, tt.ok)
+        }
+    }
+}
@@ -0,0 +1,78 @@
+package winreg
+
+import (
+    "strings"
+)
+
+// cleanPath trims leading and trailing separators and collapses empty
+// segments, so `\A\\B\` becomes `A\B`.
+func cleanPath(keyPath string) string {
+    parts := strings.Split(keyPath, `\`)
+    out := parts[:0]
+    for _, p := range parts {
+        if p != "" {
+            out = append(out, p)
+        }
+    }
+    return strings.Join(out, `\`)
+}
+
+// parentPath returns the parent of keyPath, or "" for a top-level key.
+func parentPath(keyPath string) string {
+    keyPath = cleanPath(keyPath)
+    if i := strings.LastIndex(keyPath, `\`); i >= 0 {
+        return keyPath[:i]
+    }
+    return ""
+}
+
+// hasPathPrefix reports whether keyPath is prefix or lies beneath it.
+// Registry paths are compared case-insensitively.
+func hasPathPrefix(keyPath, prefix string) bool {
+    keyPath, prefix = cleanPath(keyPath), cleanPath(prefix)
+    if prefix == "" {
+        return true
+    }
+    if len(keyPath) < len(prefix) || !strings.EqualFold(keyPath[:len(prefix)], prefix) {
+        return false
+    }
+    return len(keyPath) == len(prefix) || keyPath[len(prefix)] == '\\'
+}
+
+// joinKeyPath appends name to the key path parent.
+func joinKeyPath(parent, name string) string {
+    if parent == "" {
+        return name
+    }
+    return parent + `\` + name
+}
+
+// Ancestors returns the paths from the top-level key down to keyPath itself,
+// e.g. `SOFTWARE`, `SOFTWARE\Microsoft`, `SOFTWARE\Microsoft\Windows` for
+// `SOFTWARE\Microsoft\Windows`. The path is cleaned first, so leading,
+// trailing and doubled separators don't produce empty entries. The root
+// itself, the empty path, has no ancestors.
+func Ancestors(keyPath string) []string {
+    keyPath = cleanPath(keyPath)
+    if keyPath == "" {
+        return nil
+    }
+
+    var paths []string
+    for i, c := range keyPath {
+        if c == '\\' {
+            paths = append(paths, keyPath[:i])
+        }
+    }
+    return append(paths, keyPath)
+}
+
+// Depth returns the number of segments in keyPath after cleaning it, so a
+// top-level key has depth 1 and the root itself depth 0.
+func Depth(keyPath string) int {
+    keyPath = cleanPath(keyPath)
+    if keyPath == "" {
+        return 0
+    }
+    return strings.Count(keyPath, `\`) + 1
+}
@@ -0,0 +1,163 @@
+package winreg
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "unicode/utf16"
+)
+
+// polSignature and polVersion start every registry.pol file.
+const (
+    polSignature = "PReg"
+    polVersion   = 1
+)
+
+// errMalformedPol is returned for .pol input that doesn't follow the format.
+var errMalformedPol = errors.New("winreg: malformed .pol file")
+
+// PolEntry is a single setting of a Group Policy registry.pol file. KeyPath
+// is relative to the hive the file applies to: HKLM for the Machine and HKCU
+// for the User policy file. Data is in the registry's raw format.
+//
+// Besides ordinary values, policy files use special value names such as
+// "**del.Name" and "**delvals." to request deletions; they are returned
+// as-is.
+type PolEntry struct {
+    KeyPath   string
+    ValueName string
+    Type      uint32
+    Data      []byte
+}
+
+// Value returns the entry's data decoded as described on TypedValue.
+func (e PolEntry) Value() TypedValue {
+    return TypedValue{Type: e.Type, Data: decodeValue(e.Type, e.Data), Raw: e.Data}
+}
+
+// ReadPolFile parses a registry.pol file. The format is a "PReg" signature
+// and version followed by entries of the form [key;value;type;size;data],
+// where the punctuation and strings are UTF-16LE.
+func ReadPolFile(r io.Reader) ([]PolEntry, error) {
+    br := bufio.NewReader(r)
+
+    var header [8]byte
+    if _, err := io.ReadFull(br, header[:]); err != nil {
+        return nil, errMalformedPol
+    }
+    if string(header[:4]) != polSignature || binary.LittleEndian.Uint32(header[4:]) != polVersion {
+        return nil, errMalformedPol
+    }
+
+    var entries []PolEntry
+    for {
+        c, err := readPolChar(br)
+        if err == io.EOF {
+            return entries, nil
+        }
+        if err != nil || c != '[' {
+            return nil, errMalformedPol
+        }
+
+        var e PolEntry
+        if e.KeyPath, err = readPolString(br); err != nil {
+            return nil, err
+        }
+        if e.ValueName, err = readPolString(br); err != nil {
+            return nil, err
+        }
+        var fields [2]uint32
+        for i := range fields {
+            if err := binary.Read(br, binary.LittleEndian, &fields[i]); err != nil {
+                return nil, errMalformedPol
+            }
+            if err := expectPolChar(br, ';'); err != nil {
+                return nil, err
+            }
+        }
+        e.Type = fields[0]
+        // The size field is not trusted for an allocation; a truncated file
+        // only costs as much memory as it actually holds.
+        var data bytes.Buffer
+        if _, err := io.CopyN(&data, br, int64(fields[1])); err != nil {
+            return nil, errMalformedPol
+        }
+        e.Data = data.Bytes()
+        if err := expectPolChar(br, ']'); err != nil {
+            return nil, err
+        }
+
+        entries = append(entries, e)
+    }
+}
+
+// WritePolFile writes entries as a registry.pol file in the format read by
+// ReadPolFile.
+func WritePolFile(w io.Writer, entries []PolEntry) error {
+    bw := bufio.NewWriter(w)
+    bw.WriteString(polSignature)
+    binary.Write(bw, binary.LittleEndian, uint32(polVersion))
+
+    for _, e := range entries {
+        writePolChars(bw, "[")
+        writePolChars(bw, e.KeyPath+"\x00;")
+        writePolChars(bw, e.ValueName+"\x00;")
+        binary.Write(bw, binary.LittleEndian, e.Type)
+        writePolChars(bw, ";")
+        binary.Write(bw, binary.LittleEndian, uint32(len(e.Data)))
+        writePolChars(bw, ";")
+        bw.Write(e.Data)
+        writePolChars(bw, "]")
+    }
+
+    return bw.Flush()
+}
+
+// readPolChar reads one UTF-16LE code unit.
+func readPolChar(br *bufio.Reader) (uint16, error) {
+    var b [2]byte
+    if _, err := io.ReadFull(br, b[:]); err != nil {
+        if err == io.ErrUnexpectedEOF {
+            return 0, errMalformedPol
+        }
+        return 0, err
+    }
+    return binary.LittleEndian.Uint16(b[:]), nil
+}
+
+// expectPolChar reads one code unit and checks that it is want.
+func expectPolChar(br *bufio.Reader, want uint16) error {
+    c, err := readPolChar(br)
+    if err != nil || c != want {
+        return fmt.Errorf("%w: expected %q", errMalformedPol, rune(want))
+    }
+    return nil
+}
+
+// readPolString reads a null-terminated UTF-16LE string and the ';' that
+// follows it.
+func readPolString(br *bufio.Reader) (string, error) {
+    var u []uint16
+    for {
+        c, err := readPolChar(br)
+        if err != nil {
+            return "", errMalformedPol
+        }
+        if c == 0 {
+            break
+        }
+        u = append(u, c)
+    }
+    if err := expectPolChar(br, ';'); err != nil {
+        return "", err
+    }
+    return string(utf16.Decode(u)), nil
+}
+
+// writePolChars writes s as UTF-16LE without a terminator.
+func writePolChars(bw *bufio.Writer, s string) {
+    bw.Write(encodeUTF16(s))
+}
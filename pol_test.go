@@ -0,0 +1,104 @@
+package winreg
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "testing"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// polBytes assembles .pol input: strings are written as UTF-16LE, uint32s
+// in little-endian order and byte slices as they are.
+func polBytes(parts ...any) []byte {
+    var buf bytes.Buffer
+    for _, p := range parts {
+        switch p := p.(type) {
+        case string:
+            buf.Write(encodeUTF16(p))
+        case uint32:
+            binary.Write(&buf, binary.LittleEndian, p)
+        case []byte:
+            buf.Write(p)
+        }
+    }
+    return buf.Bytes()
+}
+
+// polHeader is the signature and version that start every .pol file.
+var polHeader = []byte("PReg\x01\x00\x00\x00")
+
+func TestPolFileRoundTrip(t *testing.T) {
+    entries := []PolEntry{
+        {`Software\Policies\App`, "Enabled", registry.DWORD, []byte{1, 0, 0, 0}},
+        {`Software\Policies\App`, "Name", registry.SZ, szRaw("Grüße \U0001D11E")},
+        {`Software\Policies\App`, "**del.Old", registry.SZ, szRaw(" ")},
+        {`Software\Policies\App\Sub`, "**delvals.", registry.SZ, szRaw(" ")},
+        {`Software\Policies\App`, "", registry.BINARY, []byte{0, ']', ';', '[', 0xff}},
+        {`Software\Policies\App`, "Empty", registry.NONE, nil},
+    }
+
+    var buf bytes.Buffer
+    if err := WritePolFile(&buf, entries); err != nil {
+        t.Fatal(err)
+    }
+    got, err := ReadPolFile(bytes.NewReader(buf.Bytes()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(got) != len(entries) {
+        t.Fatalf("read %d entries, want %d", len(got), len(entries))
+    }
+    for i, e := range entries {
+        g := got[i]
+        if g.KeyPath != e.KeyPath || g.ValueName != e.ValueName || g.Type != e.Type || !bytes.Equal(g.Data, e.Data) {
+            t.Errorf("entry %d = %+v, want %+v", i, g, e)
+        }
+    }
+
+    // An empty file is just the header.
+    got, err = ReadPolFile(bytes.NewReader(polHeader))
+    if err != nil || len(got) != 0 {
+        t.Errorf("ReadPolFile(header only) = %v, %v; want no entries", got, err)
+    }
+}
+
+func TestReadPolFileTruncated(t *testing.T) {
+    var buf bytes.Buffer
+    err := WritePolFile(&buf, []PolEntry{{`Software\Policies\App`, "Enabled", registry.DWORD, []byte{1, 0, 0, 0}}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    full := buf.Bytes()
+
+    // Every cut inside the entry must be reported, not read as a shorter
+    // entry.
+    for n := len(polHeader) + 1; n < len(full); n++ {
+        if _, err := ReadPolFile(bytes.NewReader(full[:n])); !errors.Is(err, errMalformedPol) {
+            t.Errorf("ReadPolFile(first %d of %d bytes) error = %v, want errMalformedPol", n, len(full), err)
+        }
+    }
+}
+
+func TestReadPolFileMalformed(t *testing.T) {
+    tests := []struct {
+        name string
+        in   []byte
+    }{
+        {"empty", nil},
+        {"bad signature", []byte("PRex\x01\x00\x00\x00")},
+        {"bad version", []byte("PReg\x02\x00\x00\x00")},
+        {"oversized size", polBytes(polHeader, "[Key\x00;Name\x00;", uint32(registry.BINARY), ";", uint32(0xffffffff), ";", []byte{1, 2, 3, 4}, "]")},
+        {"size past the end", polBytes(polHeader, "[Key\x00;Name\x00;", uint32(registry.BINARY), ";", uint32(6), ";", []byte{1, 2, 3, 4}, "]")},
+        {"undersized size", polBytes(polHeader, "[Key\x00;Name\x00;", uint32(registry.BINARY), ";", uint32(2), ";", []byte{1, 2, 3, 4}, "]")},
+        {"missing bracket", polBytes(polHeader, "Key\x00;Name\x00;", uint32(registry.BINARY), ";", uint32(0), ";]")},
+        {"missing separator", polBytes(polHeader, "[Key\x00Name\x00;", uint32(registry.BINARY), ";", uint32(0), ";]")},
+        {"unterminated key", polBytes(polHeader, "[Key")},
+    }
+    for _, tt := range tests {
+        if _, err := ReadPolFile(bytes.NewReader(tt.in)); !errors.Is(err, errMalformedPol) {
+            t.Errorf("%s: ReadPolFile error = %v, want errMalformedPol", tt.name, err)
+        }
+    }
+}
@@ -0,0 +1,48 @@
+package winreg
+
+import (
+    "golang.org/x/sys/windows/registry"
+)
+
+// Sources reported by ReadEffectivePolicyValue, in order of precedence.
+const (
+    SourceMachinePolicy = "MachinePolicy" // HKLM\Software\Policies
+    SourceUserPolicy    = "UserPolicy"    // HKCU\Software\Policies
+    SourceUser          = "User"          // HKCU\Software
+    SourceMachine       = "Machine"       // HKLM\Software
+)
+
+// ReadEffectivePolicyValue reads the value that is in effect for a setting
+// that Group Policy may override. productPath is relative to Software, e.g.
+// `Microsoft\Edge`. The locations are checked in the usual precedence order,
+// machine policy, user policy, user setting, machine setting, and the first
+// one holding the value wins. source tells which one it was; the
+// Source*Policy results mean the value is enforced by policy.
+//
+// The data is returned in the form documented on ReadValue. If no location
+// has the value, registry.ErrNotExist is returned.
+func ReadEffectivePolicyValue(productPath, valueName string) (data any, source string, err error) {
+    locations := []struct {
+        root   registry.Key
+        path   string
+        source string
+    }{
+        {registry.LOCAL_MACHINE, `Software\Policies\` + productPath, SourceMachinePolicy},
+        {registry.CURRENT_USER, `Software\Policies\` + productPath, SourceUserPolicy},
+        {registry.CURRENT_USER, `Software\` + productPath, SourceUser},
+        {registry.LOCAL_MACHINE, `Software\` + productPath, SourceMachine},
+    }
+
+    for _, loc := range locations {
+        data, err := ReadValue(loc.root, loc.path, valueName)
+        if err == registry.ErrNotExist {
+            continue
+        }
+        if err != nil {
+            return nil, "", err
+        }
+        return data, loc.source, nil
+    }
+
+    return nil, "", registry.ErrNotExist
+}
@@ -0,0 +1,171 @@
+package winreg
+
+import (
+    "container/list"
+    "errors"
+    "sync"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// ErrPoolClosed is returned by KeyPool.Get after the pool has been closed.
+var ErrPoolClosed = errors.New("winreg: key pool is closed")
+
+// poolID identifies a pooled handle by the arguments it was opened with.
+type poolID struct {
+    root   registry.Key
+    path   string
+    access uint32
+}
+
+type poolEntry struct {
+    id     poolID
+    key    registry.Key
+    refs   int
+    closed bool
+    elem   *list.Element
+}
+
+// KeyPool caches open registry key handles by root, path and access so that
+// repeated reads from the same keys don't pay for an OpenKey/Close pair each
+// time. A KeyPool is safe for concurrent use by multiple goroutines.
+type KeyPool struct {
+    mu      sync.Mutex
+    max     int
+    entries map[poolID]*poolEntry
+    lru     *list.List // front is most recently used
+    closed  bool
+}
+
+// NewKeyPool creates a KeyPool that keeps at most max handles open. Handles
+// that are still in use are never evicted, so the pool may briefly exceed max
+// when every cached handle is checked out. A max of zero or less means no cap.
+func NewKeyPool(max int) *KeyPool {
+    return &KeyPool{
+        max:     max,
+        entries: make(map[poolID]*poolEntry),
+        lru:     list.New(),
+    }
+}
+
+// Get returns an open handle for keyPath under root with the given access,
+// opening it if it isn't cached yet. The returned release function must be
+// called once the caller is done with the handle; the handle itself must not
+// be closed directly.
+func (p *KeyPool) Get(root registry.Key, keyPath string, access uint32) (registry.Key, func(), error) {
+    id := poolID{root: root, path: keyPath, access: access}
+
+    p.mu.Lock()
+    if p.closed {
+        p.mu.Unlock()
+        return 0, nil, ErrPoolClosed
+    }
+    if e, ok := p.entries[id]; ok {
+        e.refs++
+        p.lru.MoveToFront(e.elem)
+        p.mu.Unlock()
+        return e.key, p.releaser(e), nil
+    }
+    p.mu.Unlock()
+
+    // Open outside the lock so a slow open doesn't stall every other caller.
+    k, err := openKey(root, keyPath, access)
+    if err != nil {
+        return 0, nil, err
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if p.closed {
+        k.Close()
+        return 0, nil, ErrPoolClosed
+    }
+    if e, ok := p.entries[id]; ok {
+        // Another goroutine opened the same key while we were unlocked.
+        k.Close()
+        e.refs++
+        p.lru.MoveToFront(e.elem)
+        return e.key, p.releaser(e), nil
+    }
+
+    e := &poolEntry{id: id, key: k, refs: 1}
+    e.elem = p.lru.PushFront(e)
+    p.entries[id] = e
+    p.evict()
+
+    return e.key, p.releaser(e), nil
+}
+
+// releaser returns a function that gives up one reference to e. Calling it
+// more than once has no further effect.
+func (p *KeyPool) releaser(e *poolEntry) func() {
+    var once sync.Once
+    return func() {
+        once.Do(func() {
+            p.mu.Lock()
+            defer p.mu.Unlock()
+
+            e.refs--
+            if e.refs == 0 && p.closed && !e.closed {
+                e.closed = true
+                e.key.Close()
+            }
+            if !p.closed {
+                p.evict()
+            }
+        })
+    }
+}
+
+// evict closes least recently used idle handles until the pool is within its
+// cap. p.mu must be held.
+func (p *KeyPool) evict() {
+    if p.max <= 0 {
+        return
+    }
+    for el := p.lru.Back(); el != nil && p.lru.Len() > p.max; {
+        prev := el.Prev()
+        e := el.Value.(*poolEntry)
+        if e.refs == 0 {
+            p.lru.Remove(el)
+            delete(p.entries, e.id)
+            e.closed = true
+            e.key.Close()
+        }
+        el = prev
+    }
+}
+
+// Len returns the number of handles currently held by the pool.
+func (p *KeyPool) Len() int {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    return p.lru.Len()
+}
+
+// Close releases every handle held by the pool. Handles that are still
+// checked out are closed as soon as their release function is called.
+func (p *KeyPool) Close() error {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if p.closed {
+        return nil
+    }
+    p.closed = true
+
+    var errs []error
+    for _, e := range p.entries {
+        if e.refs == 0 && !e.closed {
+            e.closed = true
+            if err := e.key.Close(); err != nil {
+                errs = append(errs, err)
+            }
+        }
+    }
+    p.entries = nil
+    p.lru.Init()
+
+    return errors.Join(errs...)
+}
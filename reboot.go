@@ -0,0 +1,118 @@
+package winreg
+
+import (
+    "errors"
+    "slices"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// rebootMarkerName is the subkey of an application's key where
+// MarkPendingReboot records its reasons. The key is volatile, so the
+// reasons are discarded by the restart they ask for.
+const rebootMarkerName = "RebootPending"
+
+// rebootReasonsValue is the MULTI_SZ value beneath the marker key that
+// lists the reasons.
+const rebootReasonsValue = "Reasons"
+
+// rebootPendingKeys are the keys whose mere existence means that Windows
+// waits for a restart to finish servicing or updating.
+var rebootPendingKeys = []struct {
+    path, reason string
+}{
+    {`SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\RebootPending`, "Component Based Servicing"},
+    {`SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired`, "Windows Update"},
+}
+
+// MarkPendingReboot records that a restart is needed for reason to take
+// effect, for example after changing a setting that is only read at boot.
+// appKeyPath is the application's own key under HKEY_LOCAL_MACHINE, such as
+// `SOFTWARE\Contoso\App`, so that applications don't see each other's
+// reasons. The reasons are kept in its volatile RebootPending subkey, which
+// Windows discards on the next restart, so they never need to be cleared.
+// Recording the same reason twice has no further effect. Writing to
+// HKEY_LOCAL_MACHINE requires an elevated process.
+//
+// PendingFileRenameOperations is deliberately left alone: Windows carries
+// out its entries at boot, so it cannot hold a mere note.
+func MarkPendingReboot(appKeyPath, reason string) (err error) {
+    appKeyPath = cleanPath(appKeyPath)
+    if appKeyPath == "" {
+        return errors.New("winreg: empty application key path")
+    }
+    if reason == "" {
+        return errors.New("winreg: empty reboot reason")
+    }
+
+    defer auditValue("MarkPendingReboot", registry.LOCAL_MACHINE, joinKeyPath(appKeyPath, rebootMarkerName), rebootReasonsValue, reason)(&err)
+
+    parent, err := createKey(registry.LOCAL_MACHINE, appKeyPath, 0, registry.CREATE_SUB_KEY)
+    if err != nil {
+        return err
+    }
+    defer parent.Close()
+
+    k, err := createKey(parent, rebootMarkerName, regOptionVolatile, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    reasons, _, err := k.GetStringsValue(rebootReasonsValue)
+    if err != nil && err != registry.ErrNotExist {
+        return err
+    }
+    if slices.Contains(reasons, reason) {
+        return nil
+    }
+
+    return k.SetStringsValue(rebootReasonsValue, append(reasons, reason))
+}
+
+// IsRebootPending reports whether a restart is pending and why. It checks
+// the places Windows uses, returning "Component Based Servicing", "Windows
+// Update" or the name of a non-empty PendingFileRenameOperations value as
+// the reason, followed by the reasons recorded with MarkPendingReboot under
+// appKeyPath. Pass an empty appKeyPath to check only the Windows state.
+func IsRebootPending(appKeyPath string) (bool, []string, error) {
+    var reasons []string
+
+    for _, p := range rebootPendingKeys {
+        k, err := openKey(registry.LOCAL_MACHINE, p.path, registry.QUERY_VALUE)
+        if err == registry.ErrNotExist {
+            continue
+        }
+        if err != nil {
+            return false, nil, err
+        }
+        k.Close()
+        reasons = append(reasons, p.reason)
+    }
+
+    sm, err := openKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Control\Session Manager`, registry.QUERY_VALUE)
+    if err != nil {
+        return false, nil, err
+    }
+    defer sm.Close()
+
+    for _, name := range []string{"PendingFileRenameOperations", "PendingFileRenameOperations2"} {
+        ops, _, err := sm.GetStringsValue(name)
+        if err != nil && err != registry.ErrNotExist {
+            return false, nil, err
+        }
+        if len(ops) > 0 {
+            reasons = append(reasons, name)
+        }
+    }
+
+    if appKeyPath = cleanPath(appKeyPath); appKeyPath != "" {
+        marked, err := ReadMultiStringValue(registry.LOCAL_MACHINE, joinKeyPath(appKeyPath, rebootMarkerName), rebootReasonsValue)
+        if err != nil && err != registry.ErrNotExist {
+            return false, nil, err
+        }
+        reasons = append(reasons, marked...)
+    }
+
+    return len(reasons) > 0, reasons, nil
+}
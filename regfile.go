@@ -0,0 +1,262 @@
+package winreg
+
+import (
+    "bufio"
+    "encoding/binary"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "unicode"
+    "unicode/utf16"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// regFileWriter writes strings as UTF-16LE, the encoding regedit uses.
+type regFileWriter struct {
+    w *bufio.Writer
+}
+
+func (rw *regFileWriter) WriteString(s string) {
+    var b [2]byte
+    for _, c := range utf16.Encode([]rune(s)) {
+        binary.LittleEndian.PutUint16(b[:], c)
+        rw.w.Write(b[:])
+    }
+}
+
+// regFileReader reads a .reg file line by line. Files written by regedit are
+// UTF-16LE with a byte order mark; anything else is read as UTF-8.
+type regFileReader struct {
+    r     *bufio.Reader
+    utf16 bool
+}
+
+func newRegFileReader(r io.Reader) *regFileReader {
+    rr := &regFileReader{r: bufio.NewReader(r)}
+    if bom, err := rr.r.Peek(2); err == nil && bom[0] == 0xff && bom[1] == 0xfe {
+        rr.r.Discard(2)
+        rr.utf16 = true
+    } else if bom, err := rr.r.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+        rr.r.Discard(3)
+    }
+    return rr
+}
+
+// ReadLine returns the next line without its line break, or io.EOF once the
+// input is exhausted.
+func (rr *regFileReader) ReadLine() (string, error) {
+    if !rr.utf16 {
+        line, err := rr.r.ReadString('\n')
+        if err == io.EOF && line != "" {
+            err = nil
+        }
+        return strings.TrimRight(line, "\r\n"), err
+    }
+
+    var u []uint16
+    var b [2]byte
+    for {
+        if _, err := io.ReadFull(rr.r, b[:]); err != nil {
+            if err == io.EOF && len(u) > 0 {
+                break
+            }
+            return "", err
+        }
+        c := binary.LittleEndian.Uint16(b[:])
+        if c == '\n' {
+            break
+        }
+        u = append(u, c)
+    }
+    return strings.TrimRight(string(utf16.Decode(u)), "\r"), nil
+}
+
+// formatRegFileValue formats one value as a .reg line, including the line
+// break. Long hex data is wrapped the way regedit does it.
+func formatRegFileValue(name string, typ uint32, raw []byte) string {
+    var b strings.Builder
+    if name == "" {
+        b.WriteString("@=")
+    } else {
+        b.WriteString(`"` + escapeRegFileString(name) + `"=`)
+    }
+
+    switch {
+    case typ == registry.SZ && isPlainRegFileString(raw):
+        b.WriteString(`"` + escapeRegFileString(decodeString(raw)) + "\"\r\n")
+        return b.String()
+    case typ == registry.DWORD && len(raw) == 4:
+        fmt.Fprintf(&b, "dword:%08x\r\n", binary.LittleEndian.Uint32(raw))
+        return b.String()
+    }
+
+    b.WriteString(hexTypePrefix(typ) + ":")
+    col := b.Len()
+    for i, c := range raw {
+        fmt.Fprintf(&b, "%02x", c)
+        col += 2
+        if i < len(raw)-1 {
+            b.WriteByte(',')
+            col++
+            if col > 76 {
+                b.WriteString("\\\r\n  ")
+                col = 2
+            }
+        }
+    }
+    b.WriteString("\r\n")
+
+    return b.String()
+}
+
+// RegFileTypePrefix returns the prefix that introduces data of type typ in a
+// .reg file: "dword" for DWORD, "hex" for BINARY and "hex(N)" for the other
+// types, for example "hex(7)" for MULTI_SZ. SZ data is written as a quoted
+// string without a prefix, so the empty string is returned for it.
+func RegFileTypePrefix(typ uint32) string {
+    switch typ {
+    case registry.SZ:
+        return ""
+    case registry.DWORD:
+        return "dword"
+    }
+    return hexTypePrefix(typ)
+}
+
+// hexTypePrefix returns the .reg prefix for hex-encoded data of type typ.
+func hexTypePrefix(typ uint32) string {
+    if typ == registry.BINARY {
+        return "hex"
+    }
+    return fmt.Sprintf("hex(%x)", typ)
+}
+
+// ParseRegFileValue decodes the data part of a .reg value line, the text
+// after the '=', such as `"text"`, `dword:0000002a` or `hex(7):41,00,00,00`.
+// Hex data may span several lines joined with a trailing backslash, as
+// regedit writes it. The returned data is in the registry's raw format.
+func ParseRegFileValue(token string) (typ uint32, data []byte, err error) {
+    token = strings.TrimSpace(token)
+
+    switch {
+    case strings.HasPrefix(token, `"`):
+        s, rest, ok := unquoteRegFileString(token)
+        if !ok || strings.TrimSpace(rest) != "" {
+            return 0, nil, fmt.Errorf("winreg: malformed string data %q", token)
+        }
+        return registry.SZ, encodeUTF16(s + "\x00"), nil
+
+    case strings.HasPrefix(token, "dword:"):
+        digits := token[len("dword:"):]
+        v, err := strconv.ParseUint(digits, 16, 32)
+        if err != nil || len(digits) > 8 {
+            return 0, nil, fmt.Errorf("winreg: malformed dword data %q", token)
+        }
+        return registry.DWORD, binary.LittleEndian.AppendUint32(nil, uint32(v)), nil
+
+    case strings.HasPrefix(token, "hex:"):
+        typ, token = registry.BINARY, token[len("hex:"):]
+
+    case strings.HasPrefix(token, "hex("):
+        end := strings.Index(token, "):")
+        if end < 0 {
+            return 0, nil, fmt.Errorf("winreg: malformed hex type in %q", token)
+        }
+        t, err := strconv.ParseUint(token[len("hex("):end], 16, 32)
+        if err != nil {
+            return 0, nil, fmt.Errorf("winreg: malformed hex type in %q", token)
+        }
+        typ, token = uint32(t), token[end+len("):"):]
+
+    default:
+        return 0, nil, fmt.Errorf("winreg: unrecognized value data %q", token)
+    }
+
+    data, err = parseRegFileHex(token)
+    if err != nil {
+        return 0, nil, err
+    }
+    return typ, data, nil
+}
+
+// parseRegFileHex decodes comma separated hex bytes, joining continuation
+// lines and ignoring whitespace.
+func parseRegFileHex(s string) ([]byte, error) {
+    s = strings.Map(func(r rune) rune {
+        switch r {
+        case '\\', ' ', '\t', '\r', '\n':
+            return -1
+        }
+        return r
+    }, s)
+    if s == "" {
+        return nil, nil
+    }
+
+    parts := strings.Split(strings.TrimSuffix(s, ","), ",")
+    data := make([]byte, len(parts))
+    for i, p := range parts {
+        b, err := strconv.ParseUint(p, 16, 8)
+        if err != nil {
+            return nil, fmt.Errorf("winreg: malformed hex byte %q", p)
+        }
+        data[i] = byte(b)
+    }
+    return data, nil
+}
+
+// unquoteRegFileString parses a quoted .reg string at the start of s and
+// returns its unescaped content and the text after the closing quote.
+func unquoteRegFileString(s string) (val, rest string, ok bool) {
+    if !strings.HasPrefix(s, `"`) {
+        return "", s, false
+    }
+    var b strings.Builder
+    for i := 1; i < len(s); i++ {
+        switch c := s[i]; c {
+        case '"':
+            return b.String(), s[i+1:], true
+        case '\\':
+            i++
+            if i == len(s) {
+                return "", s, false
+            }
+            b.WriteByte(s[i])
+        default:
+            b.WriteByte(c)
+        }
+    }
+    return "", s, false
+}
+
+// isPlainRegFileString reports whether SZ data can be written as a quoted
+// string and read back to the same bytes.
+func isPlainRegFileString(raw []byte) bool {
+    u := bytesToUTF16(raw)
+    if len(raw)%2 != 0 || len(u) == 0 || u[len(u)-1] != 0 {
+        return false
+    }
+    u = u[:len(u)-1]
+    for i := 0; i < len(u); i++ {
+        c := u[i]
+        if c == 0 || c == '\r' || c == '\n' {
+            return false
+        }
+        // Surrogate pairs survive the UTF-16 .reg encoding, but an unpaired
+        // surrogate would be replaced by U+FFFD when decoded.
+        if utf16.IsSurrogate(rune(c)) {
+            if i+1 == len(u) || utf16.DecodeRune(rune(c), rune(u[i+1])) == unicode.ReplacementChar {
+                return false
+            }
+            i++
+        }
+    }
+    return true
+}
+
+// escapeRegFileString escapes backslashes and quotes for a .reg file.
+func escapeRegFileString(s string) string {
+    return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
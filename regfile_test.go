@@ -0,0 +1,71 @@
+package winreg
+
+import (
+    "bytes"
+    "encoding/binary"
+    "strings"
+    "testing"
+    "unicode/utf16"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// utf16Raw returns the code units as little-endian registry data.
+func utf16Raw(u ...uint16) []byte {
+    raw := make([]byte, 2*len(u))
+    for i, c := range u {
+        binary.LittleEndian.PutUint16(raw[2*i:], c)
+    }
+    return raw
+}
+
+// szRaw returns s as null-terminated SZ data.
+func szRaw(s string) []byte {
+    return utf16Raw(append(utf16.Encode([]rune(s)), 0)...)
+}
+
+func TestIsPlainRegFileString(t *testing.T) {
+    tests := []struct {
+        name string
+        raw  []byte
+        want bool
+    }{
+        {"ascii", szRaw("hello"), true},
+        {"bmp", szRaw("grüße ☃"), true},
+        {"astral", szRaw("smile \U0001F600 and 𝄞"), true},
+        {"only astral", szRaw("\U0001F600"), true},
+        {"lone high surrogate", utf16Raw('a', 0xd83d, 'b', 0), false},
+        {"lone high surrogate at end", utf16Raw('a', 0xd83d, 0), false},
+        {"lone low surrogate", utf16Raw('a', 0xde00, 0), false},
+        {"reversed pair", utf16Raw(0xde00, 0xd83d, 0), false},
+        {"unterminated", utf16Raw('a', 'b'), false},
+        {"odd length", append(szRaw("a"), 0), false},
+        {"embedded null", utf16Raw('a', 0, 'b', 0), false},
+        {"line break", szRaw("a\nb"), false},
+    }
+    for _, tt := range tests {
+        if got := isPlainRegFileString(tt.raw); got != tt.want {
+            t.Errorf("%s: isPlainRegFileString = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestRegFileValueRoundTripAstral(t *testing.T) {
+    for _, raw := range [][]byte{
+        szRaw("emoji \U0001F600 in a string"),
+        utf16Raw('x', 0xd83d, 'y', 0),
+    } {
+        line := strings.TrimSpace(formatRegFileValue("v", registry.SZ, raw))
+        name, data, err := splitRegFileValueLine(strings.ReplaceAll(line, "\\\r\n  ", ""))
+        if err != nil {
+            t.Fatalf("splitRegFileValueLine(%q): %v", line, err)
+        }
+        typ, got, err := ParseRegFileValue(data)
+        if err != nil {
+            t.Fatalf("ParseRegFileValue(%q): %v", data, err)
+        }
+        if name != "v" || typ != registry.SZ || !bytes.Equal(got, raw) {
+            t.Errorf("round trip of %x via %q = %q, %d, %x", raw, line, name, typ, got)
+        }
+    }
+}
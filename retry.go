@@ -0,0 +1,107 @@
+package winreg
+
+import (
+    "errors"
+    "sync"
+    "time"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// RetryPolicy tells the package how to retry opening keys that fail with a
+// transient error, for example while a remote machine's registry service is
+// busy. It applies to every function of the package that opens or creates a
+// key, except Transaction.OpenKey and CurrentUserKey.
+type RetryPolicy struct {
+    // Attempts is the total number of tries, including the first one.
+    // Values below 2 disable retrying.
+    Attempts int
+
+    // Delay is the wait before the first retry. It doubles with every
+    // further retry, up to MaxDelay if that is set.
+    Delay    time.Duration
+    MaxDelay time.Duration
+
+    // Retryable reports whether an error is worth retrying. If nil, every
+    // error is retried except registry.ErrNotExist and access denied,
+    // which retrying won't fix.
+    Retryable func(error) bool
+}
+
+var (
+    retryMu     sync.RWMutex
+    retryPolicy *RetryPolicy
+)
+
+// SetRetryPolicy installs p as the retry policy for opening keys, replacing
+// any previous one. Passing nil, the default, disables retrying. The policy
+// is meant to be set once at startup but may be changed at any time; it must
+// not be modified after it has been installed.
+func SetRetryPolicy(p *RetryPolicy) {
+    retryMu.Lock()
+    defer retryMu.Unlock()
+    retryPolicy = p
+}
+
+func currentRetryPolicy() *RetryPolicy {
+    retryMu.RLock()
+    defer retryMu.RUnlock()
+    return retryPolicy
+}
+
+// openKey is registry.OpenKey governed by the retry policy. Together with
+// openKeyEx, openKeyLink and createKey it covers all key opens and creates
+// of the package except Transaction.OpenKey and CurrentUserKey.
+func openKey(k registry.Key, path string, access uint32) (registry.Key, error) {
+    return openKeyEx(k, path, 0, access)
+}
+
+// openKeyEx is openKey with REG_OPTION_* flags for RegOpenKeyEx.
+func openKeyEx(k registry.Key, path string, options, access uint32) (registry.Key, error) {
+    return withRetry(func() (registry.Key, error) {
+        return regOpenKeyEx(k, path, options, access)
+    })
+}
+
+// openKeyLink is openKey without following a symbolic link at the last path
+// component.
+func openKeyLink(k registry.Key, path string, access uint32) (registry.Key, error) {
+    return openKeyEx(k, path, regOptionOpenLink, access)
+}
+
+// createKey is registry.CreateKey with REG_OPTION_* flags for
+// RegCreateKeyEx, governed by the retry policy.
+func createKey(k registry.Key, path string, options, access uint32) (registry.Key, error) {
+    return withRetry(func() (registry.Key, error) {
+        return regCreateKeyEx(k, path, options, access)
+    })
+}
+
+// withRetry calls open, retrying it as the retry policy says.
+func withRetry(open func() (registry.Key, error)) (registry.Key, error) {
+    key, err := open()
+    p := currentRetryPolicy()
+    if err == nil || p == nil {
+        return key, err
+    }
+
+    delay := p.Delay
+    for attempt := 1; attempt < p.Attempts && p.retryable(err); attempt++ {
+        time.Sleep(delay)
+        if delay *= 2; p.MaxDelay > 0 && delay > p.MaxDelay {
+            delay = p.MaxDelay
+        }
+        if key, err = open(); err == nil {
+            return key, nil
+        }
+    }
+    return 0, err
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+    if p.Retryable != nil {
+        return p.Retryable(err)
+    }
+    return err != registry.ErrNotExist && !errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
@@ -0,0 +1,51 @@
+package winreg
+
+import (
+    "fmt"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// RootKeyInfo describes one of the predefined root keys.
+type RootKeyInfo struct {
+    Key       registry.Key
+    ShortName string // e.g. "HKLM"
+    LongName  string // e.g. "HKEY_LOCAL_MACHINE", as used in .reg files
+}
+
+// rootKeys lists the predefined root keys in the order regedit shows them.
+var rootKeys = []RootKeyInfo{
+    {registry.CLASSES_ROOT, "HKCR", "HKEY_CLASSES_ROOT"},
+    {registry.CURRENT_USER, "HKCU", "HKEY_CURRENT_USER"},
+    {registry.LOCAL_MACHINE, "HKLM", "HKEY_LOCAL_MACHINE"},
+    {registry.USERS, "HKU", "HKEY_USERS"},
+    {registry.CURRENT_CONFIG, "HKCC", "HKEY_CURRENT_CONFIG"},
+    {registry.PERFORMANCE_DATA, "HKPD", "HKEY_PERFORMANCE_DATA"},
+}
+
+// rootKeyNames maps the predefined root keys to the names used in .reg files.
+var rootKeyNames = func() map[registry.Key]string {
+    m := make(map[registry.Key]string, len(rootKeys))
+    for _, r := range rootKeys {
+        m[r.Key] = r.LongName
+    }
+    return m
+}()
+
+// RootKeys returns the predefined root keys with their short and long names,
+// in the order regedit shows them.
+func RootKeys() []RootKeyInfo {
+    return append([]RootKeyInfo(nil), rootKeys...)
+}
+
+// ParseRootKey returns the predefined root key with the given short or long
+// name, such as "HKLM" or "HKEY_LOCAL_MACHINE", compared case-insensitively.
+func ParseRootKey(name string) (registry.Key, error) {
+    for _, r := range rootKeys {
+        if strings.EqualFold(name, r.ShortName) || strings.EqualFold(name, r.LongName) {
+            return r.Key, nil
+        }
+    }
+    return 0, fmt.Errorf("winreg: unknown root key %q", name)
+}
@@ -0,0 +1,224 @@
+package winreg
+
+import (
+    "bytes"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// SchemaViolation describes a value that does not match the schema given to
+// ValidateSchema. Got is meaningless if Missing is set.
+type SchemaViolation struct {
+    Name    string
+    Want    uint32
+    Got     uint32
+    Missing bool
+}
+
+func (v SchemaViolation) String() string {
+    if v.Missing {
+        return fmt.Sprintf("%q: missing, want %s", v.Name, TypeName(v.Want))
+    }
+    return fmt.Sprintf("%q: type %s, want %s", v.Name, TypeName(v.Got), TypeName(v.Want))
+}
+
+// ValidateSchema checks the values of the key at keyPath against schema,
+// which maps value names to their expected type. It returns a violation for
+// every value that is missing or has another type, sorted by name. Values
+// not mentioned in schema are ignored.
+func ValidateSchema(root registry.Key, keyPath string, schema map[string]uint32) ([]SchemaViolation, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    return validateSchema(k, schema)
+}
+
+// RepairSchema is like ValidateSchema but rewrites values of the wrong type
+// whose data can be converted, for example the string "1" to the DWORD 1 or
+// a DWORD to its decimal string. Strings are converted to numbers as
+// ReadStringAsInt parses them, in decimal or with a 0x prefix in hex. Only
+// the violations that could not be repaired, including every missing value,
+// are returned.
+func RepairSchema(root registry.Key, keyPath string, schema map[string]uint32) ([]SchemaViolation, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    violations, err := validateSchema(k, schema)
+    if err != nil {
+        return nil, err
+    }
+
+    remaining := violations[:0]
+    for _, v := range violations {
+        if !v.Missing {
+            tv, err := readTypedValue(k, v.Name)
+            if err != nil {
+                return nil, err
+            }
+            if data, ok := coerceValue(tv, v.Want); ok {
+                if err := repairValue(root, keyPath, k, v.Name, v.Want, data); err != nil {
+                    return nil, err
+                }
+                continue
+            }
+        }
+        remaining = append(remaining, v)
+    }
+
+    return remaining, nil
+}
+
+// repairValue writes the converted data of one value.
+func repairValue(root registry.Key, keyPath string, k registry.Key, valueName string, typ uint32, data any) (err error) {
+    defer auditValue("RepairSchema", root, keyPath, valueName, data)(&err)
+
+    raw, err := encodeValue(typ, data)
+    if err != nil {
+        return err
+    }
+    return regSetValueEx(k, valueName, typ, raw)
+}
+
+// validateSchema checks the values of the open key k against schema.
+func validateSchema(k registry.Key, schema map[string]uint32) ([]SchemaViolation, error) {
+    names := make([]string, 0, len(schema))
+    for name := range schema {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var violations []SchemaViolation
+    for _, name := range names {
+        want := schema[name]
+        _, got, err := k.GetValue(name, nil)
+        switch {
+        case err == registry.ErrNotExist:
+            violations = append(violations, SchemaViolation{Name: name, Want: want, Missing: true})
+        case err != nil:
+            return nil, err
+        case got != want:
+            violations = append(violations, SchemaViolation{Name: name, Want: want, Got: got})
+        }
+    }
+
+    return violations, nil
+}
+
+// coerceValue converts v to the decoded form of type want, if that can be
+// done without losing information.
+func coerceValue(v TypedValue, want uint32) (any, bool) {
+    var s string
+    var n uint64
+    var isString, isNumber bool
+    switch d := v.Data.(type) {
+    case string:
+        if v.Type != registry.LINK {
+            s, isString = d, true
+        }
+    case uint32:
+        n, isNumber = uint64(d), true
+    case uint64:
+        n, isNumber = d, true
+    }
+
+    switch want {
+    case registry.SZ, registry.EXPAND_SZ:
+        if isString {
+            return s, true
+        }
+        if isNumber {
+            return strconv.FormatUint(n, 10), true
+        }
+    case registry.MULTI_SZ:
+        if isString {
+            return []string{s}, true
+        }
+    case registry.DWORD, registry.DWORD_BIG_ENDIAN, registry.QWORD:
+        if isString {
+            var err error
+            if n, err = parseRegistryUint(strings.TrimSpace(s)); err != nil {
+                return nil, false
+            }
+        } else if !isNumber {
+            return nil, false
+        }
+        if want == registry.QWORD {
+            return n, true
+        }
+        if n <= 0xffffffff {
+            return uint32(n), true
+        }
+    }
+    return nil, false
+}
+
+// Mismatch describes a value that differs from the expectation given to
+// AssertValues. Got is the zero TypedValue if Missing is set.
+type Mismatch struct {
+    Name    string
+    Missing bool
+    Want    TypedValue
+    Got     TypedValue
+}
+
+func (m Mismatch) String() string {
+    switch {
+    case m.Missing:
+        return fmt.Sprintf("%q: missing", m.Name)
+    case m.Got.Type != m.Want.Type:
+        return fmt.Sprintf("%q: type %s, want %s", m.Name, TypeName(m.Got.Type), TypeName(m.Want.Type))
+    }
+    return fmt.Sprintf("%q: data %v, want %v", m.Name, m.Got.Data, m.Want.Data)
+}
+
+// AssertValues compares the values of the key at keyPath with expected and
+// returns a mismatch for every value that is missing, has another type or
+// holds other data, sorted by name. Data is compared on the raw bytes, taken
+// from Raw or else encoded from Data as WriteTypedValue would write it.
+// Values not mentioned in expected are ignored.
+func AssertValues(root registry.Key, keyPath string, expected map[string]TypedValue) ([]Mismatch, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    names := make([]string, 0, len(expected))
+    for name := range expected {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var mismatches []Mismatch
+    for _, name := range names {
+        want := expected[name]
+        wantRaw := want.Raw
+        if wantRaw == nil {
+            if wantRaw, err = encodeValue(want.Type, want.Data); err != nil {
+                return nil, fmt.Errorf("winreg: expected value %q: %w", name, err)
+            }
+        }
+
+        got, err := readTypedValue(k, name)
+        switch {
+        case err == registry.ErrNotExist:
+            mismatches = append(mismatches, Mismatch{Name: name, Missing: true, Want: want})
+        case err != nil:
+            return nil, err
+        case got.Type != want.Type || !bytes.Equal(got.Raw, wantRaw):
+            mismatches = append(mismatches, Mismatch{Name: name, Want: want, Got: got})
+        }
+    }
+
+    return mismatches, nil
+}
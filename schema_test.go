@@ -0,0 +1,39 @@
+package winreg
+
+import (
+    "reflect"
+    "testing"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+func TestCoerceValueNumbers(t *testing.T) {
+    tests := []struct {
+        in   string
+        want uint32
+        out  any // nil if the string cannot be converted
+    }{
+        {"42", registry.DWORD, uint32(42)},
+        {" 42 ", registry.DWORD, uint32(42)},
+        {"010", registry.DWORD, uint32(10)},
+        {"0x10", registry.DWORD, uint32(16)},
+        {"0XfF", registry.DWORD, uint32(255)},
+        {"4294967295", registry.DWORD, uint32(0xffffffff)},
+        {"4294967296", registry.DWORD, nil},
+        {"0xFFFFFFFFFFFFFFFF", registry.QWORD, uint64(0xffffffffffffffff)},
+        {"010", registry.QWORD, uint64(10)},
+        {"0b101", registry.DWORD, nil},
+        {"0o17", registry.DWORD, nil},
+        {"1_000", registry.DWORD, nil},
+        {"-1", registry.DWORD, nil},
+        {"+1", registry.DWORD, nil},
+        {"0x", registry.DWORD, nil},
+        {"", registry.DWORD, nil},
+    }
+    for _, tt := range tests {
+        got, ok := coerceValue(TypedValue{Type: registry.SZ, Data: tt.in}, tt.want)
+        if ok != (tt.out != nil) || !reflect.DeepEqual(got, tt.out) {
+            t.Errorf("coerceValue(%q, %s) = %v, %v; want %v", tt.in, TypeName(tt.want), got, ok, tt.out)
+        }
+    }
+}
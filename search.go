@@ -0,0 +1,144 @@
+package winreg
+
+import (
+    "errors"
+    "regexp"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// SearchOptions controls SearchSubtree. At least one of the Match fields must
+// be set.
+type SearchOptions struct {
+    // Pattern is matched against the selected names and data.
+    Pattern *regexp.Regexp
+
+    // MatchKeyNames searches the names of the keys beneath the starting
+    // key.
+    MatchKeyNames bool
+
+    // MatchValueNames searches value names. The unnamed default value is
+    // never matched by name.
+    MatchValueNames bool
+
+    // MatchData searches the data of SZ, EXPAND_SZ and MULTI_SZ values,
+    // each string of a MULTI_SZ separately. EXPAND_SZ data is searched
+    // unexpanded.
+    MatchData bool
+}
+
+// SearchField tells which part of the registry a SearchHit matched.
+type SearchField int
+
+const (
+    SearchKeyName SearchField = iota
+    SearchValueName
+    SearchData
+)
+
+// SearchHit is a match found by SearchSubtree. Path is relative to root and
+// ValueName is empty for key name matches. Match is the text the pattern
+// matched.
+type SearchHit struct {
+    Path      string
+    ValueName string
+    Field     SearchField
+    Match     string
+}
+
+// SearchSubtree looks for opts.Pattern in the subtree at keyPath, like
+// regedit's Find, and returns every hit in walk order. A value whose name
+// and data both match is reported once, for its name.
+func SearchSubtree(root registry.Key, keyPath string, opts SearchOptions) ([]SearchHit, error) {
+    if opts.Pattern == nil {
+        return nil, errors.New("winreg: search needs a pattern")
+    }
+    if !opts.MatchKeyNames && !opts.MatchValueNames && !opts.MatchData {
+        return nil, errors.New("winreg: search needs something to match")
+    }
+
+    start := cleanPath(keyPath)
+    var hits []SearchHit
+    err := WalkKeys(root, keyPath, func(path string) error {
+        if opts.MatchKeyNames && path != start {
+            name := path[strings.LastIndex(path, `\`)+1:]
+            if loc := opts.Pattern.FindStringIndex(name); loc != nil {
+                hits = append(hits, SearchHit{Path: path, Field: SearchKeyName, Match: name[loc[0]:loc[1]]})
+            }
+        }
+        if !opts.MatchValueNames && !opts.MatchData {
+            return nil
+        }
+
+        k, err := openKey(root, path, registry.QUERY_VALUE)
+        if err != nil {
+            return err
+        }
+        defer k.Close()
+
+        names, err := k.ReadValueNames(-1)
+        if err != nil {
+            return err
+        }
+        for _, name := range names {
+            if hit, ok, err := searchValue(k, name, opts); err != nil {
+                return err
+            } else if ok {
+                hit.Path = path
+                hits = append(hits, hit)
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return hits, nil
+}
+
+// searchValue matches the value name of the open key k and, for string
+// types, its data against opts. The data of other types is never read.
+func searchValue(k registry.Key, name string, opts SearchOptions) (SearchHit, bool, error) {
+    if opts.MatchValueNames && name != "" {
+        if loc := opts.Pattern.FindStringIndex(name); loc != nil {
+            return SearchHit{ValueName: name, Field: SearchValueName, Match: name[loc[0]:loc[1]]}, true, nil
+        }
+    }
+    if !opts.MatchData {
+        return SearchHit{}, false, nil
+    }
+
+    _, typ, err := k.GetValue(name, nil)
+    if err == registry.ErrNotExist {
+        return SearchHit{}, false, nil
+    }
+    if err != nil {
+        return SearchHit{}, false, err
+    }
+    if typ != registry.SZ && typ != registry.EXPAND_SZ && typ != registry.MULTI_SZ {
+        return SearchHit{}, false, nil
+    }
+
+    raw, typ, err := readRawValue(k, name)
+    if err == registry.ErrNotExist {
+        return SearchHit{}, false, nil
+    }
+    if err != nil {
+        return SearchHit{}, false, err
+    }
+    var texts []string
+    switch data := decodeValue(typ, raw).(type) {
+    case string:
+        texts = []string{data}
+    case []string:
+        texts = data
+    }
+    for _, s := range texts {
+        if loc := opts.Pattern.FindStringIndex(s); loc != nil {
+            return SearchHit{ValueName: name, Field: SearchData, Match: s[loc[0]:loc[1]]}, true, nil
+        }
+    }
+    return SearchHit{}, false, nil
+}
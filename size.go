@@ -0,0 +1,97 @@
+package winreg
+
+import (
+    "container/heap"
+    "sort"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// RegistryValue describes a value found beneath some key, as returned by
+// LargestValues. Path is relative to the root that was searched.
+type RegistryValue struct {
+    Path  string
+    Name  string
+    Size  uint64
+    Value TypedValue
+}
+
+// LargestValues walks the subtree at keyPath and returns the n values with
+// the most data, largest first. Only the sizes are queried during the walk;
+// the data is read just for the values returned. A value that disappears
+// before its data can be read is left out, so fewer than n values may be
+// returned.
+func LargestValues(root registry.Key, keyPath string, n int) ([]RegistryValue, error) {
+    if n <= 0 {
+        return nil, nil
+    }
+
+    var top valueHeap
+    err := WalkKeys(root, keyPath, func(path string) error {
+        k, err := openKey(root, path, registry.QUERY_VALUE)
+        if err != nil {
+            return err
+        }
+        defer k.Close()
+
+        names, err := k.ReadValueNames(-1)
+        if err != nil {
+            return err
+        }
+        for _, name := range names {
+            size, _, err := k.GetValue(name, nil)
+            if err == registry.ErrNotExist {
+                continue
+            }
+            if err != nil {
+                return err
+            }
+            if len(top) == n && uint64(size) <= top[0].Size {
+                continue
+            }
+            heap.Push(&top, RegistryValue{Path: path, Name: name, Size: uint64(size)})
+            if len(top) > n {
+                heap.Pop(&top)
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    sort.Slice(top, func(i, j int) bool { return top[i].Size > top[j].Size })
+
+    values := top[:0]
+    for _, v := range top {
+        tv, err := ReadTypedValue(root, v.Path, v.Name)
+        if err == registry.ErrNotExist {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        v.Value = tv
+        values = append(values, v)
+    }
+    return values, nil
+}
+
+// valueHeap is a min-heap of values by size, holding the largest values seen
+// so far with the smallest of them on top.
+type valueHeap []RegistryValue
+
+func (h valueHeap) Len() int           { return len(h) }
+func (h valueHeap) Less(i, j int) bool { return h[i].Size < h[j].Size }
+func (h valueHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *valueHeap) Push(x any) {
+    *h = append(*h, x.(RegistryValue))
+}
+
+func (h *valueHeap) Pop() any {
+    old := *h
+    v := old[len(old)-1]
+    *h = old[:len(old)-1]
+    return v
+}
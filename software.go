@@ -0,0 +1,192 @@
+package winreg
+
+import (
+    "errors"
+    "os"
+    "strings"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// Uninstall subtrees for 64-bit and 32-bit software under HKEY_LOCAL_MACHINE.
+// uninstallPathWow64 is where the 32-bit view of uninstallPath lives in the
+// 64-bit view.
+const (
+    uninstallPath      = `SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`
+    uninstallPathWow64 = `SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`
+)
+
+// InstalledApp describes an entry from the Uninstall keys.
+type InstalledApp struct {
+    DisplayName     string
+    DisplayVersion  string
+    Publisher       string
+    InstallDate     string // usually YYYYMMDD, as written by the installer
+    UninstallString string
+    KeyPath         string // path of the entry under HKEY_LOCAL_MACHINE, in the 64-bit view
+}
+
+// EnumerateInstalledSoftware returns the software registered in both the
+// 64-bit and 32-bit Uninstall keys. Both registry views are opened
+// explicitly, so a 32-bit process sees the 64-bit entries too. Entries
+// without a DisplayName are skipped and entries registered in both views
+// are only returned once.
+func EnumerateInstalledSoftware() ([]InstalledApp, error) {
+    var apps []InstalledApp
+    seen := make(map[InstalledApp]bool)
+
+    views := []struct {
+        access  uint32
+        keyPath string // the view's Uninstall key as seen from the 64-bit view
+    }{
+        {registry.WOW64_64KEY, uninstallPath},
+        {registry.WOW64_32KEY, uninstallPathWow64},
+    }
+    for _, view := range views {
+        k, err := openKey(registry.LOCAL_MACHINE, uninstallPath, registry.ENUMERATE_SUB_KEYS|view.access)
+        if err == registry.ErrNotExist {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        subKeys, err := k.ReadSubKeyNames(-1)
+        k.Close()
+        if err != nil {
+            return nil, err
+        }
+
+        for _, name := range subKeys {
+            app, ok := readInstalledApp(uninstallPath+`\`+name, view.access)
+            if !ok {
+                continue
+            }
+            app.KeyPath = view.keyPath + `\` + name
+
+            dedup := InstalledApp{
+                DisplayName:    app.DisplayName,
+                DisplayVersion: app.DisplayVersion,
+                Publisher:      app.Publisher,
+            }
+            if seen[dedup] {
+                continue
+            }
+            seen[dedup] = true
+            apps = append(apps, app)
+        }
+    }
+
+    return apps, nil
+}
+
+// readInstalledApp reads a single Uninstall entry in the registry view
+// selected by view. It reports false if the entry can't be opened or has no
+// DisplayName.
+func readInstalledApp(keyPath string, view uint32) (InstalledApp, bool) {
+    var app InstalledApp
+
+    k, err := openKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE|view)
+    if err != nil {
+        return app, false
+    }
+    defer k.Close()
+
+    app.DisplayName, _, _ = k.GetStringValue("DisplayName")
+    if app.DisplayName == "" {
+        return app, false
+    }
+    app.DisplayVersion, _, _ = k.GetStringValue("DisplayVersion")
+    app.Publisher, _, _ = k.GetStringValue("Publisher")
+    app.InstallDate, _, _ = k.GetStringValue("InstallDate")
+    app.UninstallString, _, _ = k.GetStringValue("UninstallString")
+
+    return app, true
+}
+
+// ReadUninstallCommand reads the uninstall command of the entry at
+// appKeyPath, a path under HKEY_LOCAL_MACHINE such as InstalledApp.KeyPath,
+// and splits it into the executable and its arguments. QuietUninstallString
+// is preferred over UninstallString when present. Environment variables are
+// expanded and the command line is split following the Windows rules for
+// quoting.
+//
+// Installers often register unquoted paths containing spaces, such as
+// `C:\Program Files\App\uninst.exe /S`. Like CreateProcess, the shortest
+// space-separated prefix naming an existing file is then taken as the
+// executable.
+//
+// appKeyPath is resolved in the 64-bit registry view, like
+// InstalledApp.KeyPath, so the same path works from 32-bit processes.
+func ReadUninstallCommand(appKeyPath string) (exe string, args []string, err error) {
+    k, err := openKey(registry.LOCAL_MACHINE, appKeyPath, registry.QUERY_VALUE|registry.WOW64_64KEY)
+    if err != nil {
+        return "", nil, err
+    }
+    defer k.Close()
+
+    cmd, _, err := k.GetStringValue("QuietUninstallString")
+    if err == registry.ErrNotExist || err == nil && strings.TrimSpace(cmd) == "" {
+        cmd, _, err = k.GetStringValue("UninstallString")
+    }
+    if err != nil {
+        return "", nil, err
+    }
+    if cmd, err = registry.ExpandString(cmd); err != nil {
+        return "", nil, err
+    }
+
+    return splitUninstallCommand(cmd, isFile)
+}
+
+// splitUninstallCommand splits an uninstall command line into the executable
+// and its arguments, using exists to probe unquoted executable paths.
+func splitUninstallCommand(cmd string, exists func(string) bool) (exe string, args []string, err error) {
+    cmd = strings.TrimSpace(cmd)
+    if cmd == "" {
+        return "", nil, errors.New("winreg: empty uninstall command")
+    }
+
+    if !strings.HasPrefix(cmd, `"`) {
+        for i := strings.IndexByte(cmd, ' '); i >= 0; {
+            if exists(cmd[:i]) {
+                args, err := decomposeArgs(cmd[i+1:])
+                return cmd[:i], args, err
+            }
+            next := strings.IndexByte(cmd[i+1:], ' ')
+            if next < 0 {
+                break
+            }
+            i += 1 + next
+        }
+        if exists(cmd) {
+            return cmd, nil, nil
+        }
+    }
+
+    argv, err := windows.DecomposeCommandLine(cmd)
+    if err != nil {
+        return "", nil, err
+    }
+    if len(argv) == 0 {
+        return "", nil, errors.New("winreg: empty uninstall command")
+    }
+    return argv[0], argv[1:], nil
+}
+
+// decomposeArgs splits the arguments that follow the executable on a command
+// line. DecomposeCommandLine reads its first token by the simpler rules for
+// the program name, so a placeholder program name is put in front.
+func decomposeArgs(s string) ([]string, error) {
+    argv, err := windows.DecomposeCommandLine("x " + strings.TrimLeft(s, " \t"))
+    if err != nil || len(argv) < 2 {
+        return nil, err
+    }
+    return argv[1:], nil
+}
+
+// isFile reports whether path names an existing regular file.
+func isFile(path string) bool {
+    fi, err := os.Stat(path)
+    return err == nil && fi.Mode().IsRegular()
+}
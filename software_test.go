@@ -0,0 +1,41 @@
+package winreg
+
+import (
+    "slices"
+    "testing"
+)
+
+func TestSplitUninstallCommand(t *testing.T) {
+    files := map[string]bool{
+        `C:\Program Files\App\uninst.exe`: true,
+        `C:\App\u.exe`:                    true,
+    }
+    exists := func(path string) bool { return files[path] }
+
+    tests := []struct {
+        cmd  string
+        exe  string
+        args []string
+    }{
+        {`"C:\Program Files\App\uninst.exe" /S`, `C:\Program Files\App\uninst.exe`, []string{"/S"}},
+        {`"C:\Program Files\App\uninst.exe"`, `C:\Program Files\App\uninst.exe`, nil},
+        {`"C:\Program Files\App\uninst.exe" "a\"b" c`, `C:\Program Files\App\uninst.exe`, []string{`a"b`, "c"}},
+        {`C:\Program Files\App\uninst.exe /S`, `C:\Program Files\App\uninst.exe`, []string{"/S"}},
+        {`C:\Program Files\App\uninst.exe`, `C:\Program Files\App\uninst.exe`, nil},
+        {`C:\Program Files\App\uninst.exe /LOG="C:\a b\log.txt" /S`, `C:\Program Files\App\uninst.exe`, []string{`/LOG=C:\a b\log.txt`, "/S"}},
+        {`C:\App\u.exe "a\"b" c`, `C:\App\u.exe`, []string{`a"b`, "c"}},
+        {`C:\App\u.exe "quoted arg"`, `C:\App\u.exe`, []string{"quoted arg"}},
+        {`C:\App\u.exe   /S  `, `C:\App\u.exe`, []string{"/S"}},
+        {`MsiExec.exe /X{12345678-1234-1234-1234-123456789012}`, `MsiExec.exe`, []string{"/X{12345678-1234-1234-1234-123456789012}"}},
+    }
+    for _, tt := range tests {
+        exe, args, err := splitUninstallCommand(tt.cmd, exists)
+        if err != nil || exe != tt.exe || !slices.Equal(args, tt.args) {
+            t.Errorf("splitUninstallCommand(%q) = %q, %q, %v; want %q, %q", tt.cmd, exe, args, err, tt.exe, tt.args)
+        }
+    }
+
+    if _, _, err := splitUninstallCommand("  ", exists); err == nil {
+        t.Errorf("splitUninstallCommand of blank command: want an error")
+    }
+}
@@ -0,0 +1,299 @@
+package winreg
+
+import (
+    "encoding/binary"
+    "syscall"
+    "unicode/utf16"
+    "unsafe"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// Registry APIs that golang.org/x/sys doesn't export.
+var (
+    modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
+
+    procRegSetValueExW        = modadvapi32.NewProc("RegSetValueExW")
+    procRegOpenKeyTransactedW = modadvapi32.NewProc("RegOpenKeyTransactedW")
+
+    procRegDisablePredefinedCacheEx = modadvapi32.NewProc("RegDisablePredefinedCacheEx")
+    procImpersonateLoggedOnUser     = modadvapi32.NewProc("ImpersonateLoggedOnUser")
+    procRegLoadKeyW                 = modadvapi32.NewProc("RegLoadKeyW")
+    procRegUnLoadKeyW               = modadvapi32.NewProc("RegUnLoadKeyW")
+    procRegOpenCurrentUser          = modadvapi32.NewProc("RegOpenCurrentUser")
+    procRegCreateKeyExW             = modadvapi32.NewProc("RegCreateKeyExW")
+
+    modktmw32 = windows.NewLazySystemDLL("ktmw32.dll")
+
+    procCreateTransaction   = modktmw32.NewProc("CreateTransaction")
+    procCommitTransaction   = modktmw32.NewProc("CommitTransaction")
+    procRollbackTransaction = modktmw32.NewProc("RollbackTransaction")
+
+    modntdll = windows.NewLazySystemDLL("ntdll.dll")
+
+    procNtQueryKey  = modntdll.NewProc("NtQueryKey")
+    procNtDeleteKey = modntdll.NewProc("NtDeleteKey")
+)
+
+// keyNameInformation is the KEY_INFORMATION_CLASS value for KEY_NAME_INFORMATION.
+const keyNameInformation = 3
+
+// regSetValueEx stores data under valueName with an arbitrary value type.
+// registry.Key only exposes typed setters for the common types.
+func regSetValueEx(k registry.Key, valueName string, valtype uint32, data []byte) error {
+    p, err := syscall.UTF16PtrFromString(valueName)
+    if err != nil {
+        return err
+    }
+    var pdata *byte
+    if len(data) > 0 {
+        pdata = &data[0]
+    }
+    r, _, _ := procRegSetValueExW.Call(uintptr(k), uintptr(unsafe.Pointer(p)), 0,
+        uintptr(valtype), uintptr(unsafe.Pointer(pdata)), uintptr(len(data)))
+    if r != 0 {
+        return syscall.Errno(r)
+    }
+    return nil
+}
+
+// ntQueryKeyName returns the NT path of the open key k using NtQueryKey.
+func ntQueryKeyName(k registry.Key) (string, error) {
+    buf := make([]byte, 512)
+    for {
+        var n uint32
+        r, _, _ := procNtQueryKey.Call(uintptr(k), keyNameInformation,
+            uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&n)))
+        status := windows.NTStatus(r)
+        if status == windows.STATUS_BUFFER_TOO_SMALL || status == windows.STATUS_BUFFER_OVERFLOW {
+            buf = make([]byte, n)
+            continue
+        }
+        if status != windows.STATUS_SUCCESS {
+            return "", status
+        }
+
+        // KEY_NAME_INFORMATION is a ULONG byte length followed by the name.
+        end := 4 + int(binary.LittleEndian.Uint32(buf))
+        if end > len(buf) {
+            end = len(buf)
+        }
+        return string(utf16.Decode(bytesToUTF16(buf[4:end]))), nil
+    }
+}
+
+// regOpenKeyTransacted opens path under k as part of the transaction tx.
+func regOpenKeyTransacted(k registry.Key, path string, access uint32, tx windows.Handle) (registry.Key, error) {
+    p, err := syscall.UTF16PtrFromString(path)
+    if err != nil {
+        return 0, err
+    }
+    var result registry.Key
+    r, _, _ := procRegOpenKeyTransactedW.Call(uintptr(k), uintptr(unsafe.Pointer(p)), 0,
+        uintptr(access), uintptr(unsafe.Pointer(&result)), uintptr(tx), 0)
+    if r != 0 {
+        return 0, syscall.Errno(r)
+    }
+    return result, nil
+}
+
+// createTransaction creates a KTM transaction with no timeout.
+func createTransaction() (windows.Handle, error) {
+    r, _, err := procCreateTransaction.Call(0, 0, 0, 0, 0, 0, 0)
+    if windows.Handle(r) == windows.InvalidHandle {
+        return 0, err
+    }
+    return windows.Handle(r), nil
+}
+
+// commitTransaction commits the KTM transaction tx.
+func commitTransaction(tx windows.Handle) error {
+    if r, _, err := procCommitTransaction.Call(uintptr(tx)); r == 0 {
+        return err
+    }
+    return nil
+}
+
+// rollbackTransaction rolls back the KTM transaction tx.
+func rollbackTransaction(tx windows.Handle) error {
+    if r, _, err := procRollbackTransaction.Call(uintptr(tx)); r == 0 {
+        return err
+    }
+    return nil
+}
+
+// Options for RegOpenKeyEx that registry.OpenKey cannot pass.
+const (
+    // regOptionBackupRestore is REG_OPTION_BACKUP_RESTORE, which opens a key
+    // with the access granted by the backup and restore privileges,
+    // regardless of its security descriptor.
+    regOptionBackupRestore = 0x4
+
+    // regOptionOpenLink is REG_OPTION_OPEN_LINK, which opens a symbolic link
+    // key itself rather than its target.
+    regOptionOpenLink = 0x8
+)
+
+// regOpenKeyEx is registry.OpenKey with the options argument of
+// RegOpenKeyEx exposed.
+func regOpenKeyEx(k registry.Key, path string, options, access uint32) (registry.Key, error) {
+    p, err := syscall.UTF16PtrFromString(path)
+    if err != nil {
+        return 0, err
+    }
+    var result windows.Handle
+    if err := windows.RegOpenKeyEx(windows.Handle(k), p, options, access, &result); err != nil {
+        return 0, err
+    }
+    return registry.Key(result), nil
+}
+
+// regOptionVolatile is REG_OPTION_VOLATILE, which creates a key that is kept
+// in memory only and is gone after the next restart.
+const regOptionVolatile = 0x1
+
+// regCreateKeyEx is registry.CreateKey with the options argument of
+// RegCreateKeyEx exposed. The options only matter if the key is created;
+// an existing key is opened as it is.
+func regCreateKeyEx(k registry.Key, path string, options, access uint32) (registry.Key, error) {
+    p, err := syscall.UTF16PtrFromString(path)
+    if err != nil {
+        return 0, err
+    }
+    var result windows.Handle
+    r, _, _ := procRegCreateKeyExW.Call(uintptr(k), uintptr(unsafe.Pointer(p)), 0, 0, uintptr(options), uintptr(access), 0, uintptr(unsafe.Pointer(&result)), 0)
+    if r != 0 {
+        return 0, syscall.Errno(r)
+    }
+    return registry.Key(result), nil
+}
+
+// cpACP is CP_ACP, the system's ANSI code page.
+const cpACP = 0
+
+// ansiToUTF16 converts text in the ANSI code page to UTF-16LE, the raw
+// format of registry strings. Null characters are converted like any other.
+func ansiToUTF16(data []byte) ([]byte, error) {
+    if len(data) == 0 {
+        return nil, nil
+    }
+    n, err := windows.MultiByteToWideChar(cpACP, 0, &data[0], int32(len(data)), nil, 0)
+    if err != nil {
+        return nil, err
+    }
+    u := make([]uint16, n)
+    if _, err := windows.MultiByteToWideChar(cpACP, 0, &data[0], int32(len(data)), &u[0], n); err != nil {
+        return nil, err
+    }
+
+    raw := make([]byte, 0, 2*len(u))
+    for _, c := range u {
+        raw = binary.LittleEndian.AppendUint16(raw, c)
+    }
+    return raw, nil
+}
+
+// keyDelete is the standard DELETE access right, needed by ntDeleteKey.
+const keyDelete = 0x10000
+
+// ntDeleteKey deletes the open key k itself. Unlike RegDeleteKey it works
+// on a link key opened with openKeyLink instead of deleting the target.
+func ntDeleteKey(k registry.Key) error {
+    r, _, _ := procNtDeleteKey.Call(uintptr(k))
+    if status := windows.NTStatus(r); status != windows.STATUS_SUCCESS {
+        return status
+    }
+    return nil
+}
+
+// regDisablePredefinedCacheEx stops the process from caching the handles
+// behind the predefined keys, so that HKEY_CURRENT_USER follows the token of
+// the calling thread.
+func regDisablePredefinedCacheEx() error {
+    if r, _, _ := procRegDisablePredefinedCacheEx.Call(); r != 0 {
+        return syscall.Errno(r)
+    }
+    return nil
+}
+
+// impersonateLoggedOnUser makes the calling thread impersonate token.
+func impersonateLoggedOnUser(token windows.Token) error {
+    if r, _, err := procImpersonateLoggedOnUser.Call(uintptr(token)); r == 0 {
+        return err
+    }
+    return nil
+}
+
+// regLoadKey mounts the hive file as the subkey name of k.
+func regLoadKey(k registry.Key, name, file string) error {
+    pname, err := syscall.UTF16PtrFromString(name)
+    if err != nil {
+        return err
+    }
+    pfile, err := syscall.UTF16PtrFromString(file)
+    if err != nil {
+        return err
+    }
+    r, _, _ := procRegLoadKeyW.Call(uintptr(k), uintptr(unsafe.Pointer(pname)), uintptr(unsafe.Pointer(pfile)))
+    if r != 0 {
+        return syscall.Errno(r)
+    }
+    return nil
+}
+
+// regUnLoadKey unmounts the hive mounted as the subkey name of k.
+func regUnLoadKey(k registry.Key, name string) error {
+    p, err := syscall.UTF16PtrFromString(name)
+    if err != nil {
+        return err
+    }
+    r, _, _ := procRegUnLoadKeyW.Call(uintptr(k), uintptr(unsafe.Pointer(p)))
+    if r != 0 {
+        return syscall.Errno(r)
+    }
+    return nil
+}
+
+// enablePrivileges enables the named privileges in the process token.
+func enablePrivileges(names ...string) error {
+    var token windows.Token
+    err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token)
+    if err != nil {
+        return err
+    }
+    defer token.Close()
+
+    for _, name := range names {
+        p, err := syscall.UTF16PtrFromString(name)
+        if err != nil {
+            return err
+        }
+        tp := windows.Tokenprivileges{PrivilegeCount: 1}
+        tp.Privileges[0].Attributes = windows.SE_PRIVILEGE_ENABLED
+        if err := windows.LookupPrivilegeValue(nil, p, &tp.Privileges[0].Luid); err != nil {
+            return err
+        }
+        // A privilege the token doesn't hold is not reported here; the
+        // operation that needs it fails with ERROR_PRIVILEGE_NOT_HELD.
+        if err := windows.AdjustTokenPrivileges(token, false, &tp, 0, nil, nil); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// maximumAllowed is MAXIMUM_ALLOWED, which grants whatever access the caller
+// is entitled to.
+const maximumAllowed = 0x02000000
+
+// regOpenCurrentUser opens the HKEY_CURRENT_USER hive of the user the calling
+// thread is running as, impersonated or not.
+func regOpenCurrentUser(access uint32) (registry.Key, error) {
+    var result registry.Key
+    r, _, _ := procRegOpenCurrentUser.Call(uintptr(access), uintptr(unsafe.Pointer(&result)))
+    if r != 0 {
+        return 0, syscall.Errno(r)
+    }
+    return result, nil
+}
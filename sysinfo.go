@@ -0,0 +1,82 @@
+package winreg
+
+import (
+    "time"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// currentVersionPath is the key holding the OS version information.
+const currentVersionPath = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+// WindowsVersion holds the commonly used values from the CurrentVersion key.
+type WindowsVersion struct {
+    ProductName    string // e.g. "Windows 10 Pro"
+    DisplayVersion string // e.g. "22H2"; empty on releases that predate it
+    CurrentBuild   string // e.g. "19045"
+    UBR            uint32 // update build revision
+    EditionID      string // e.g. "Professional"
+}
+
+// ReadWindowsVersion reads the OS version information from
+// HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion.
+func ReadWindowsVersion() (WindowsVersion, error) {
+    var v WindowsVersion
+
+    k, err := openKey(registry.LOCAL_MACHINE, currentVersionPath, registry.QUERY_VALUE)
+    if err != nil {
+        return v, err
+    }
+    defer k.Close()
+
+    if v.ProductName, _, err = k.GetStringValue("ProductName"); err != nil {
+        return v, err
+    }
+    if v.CurrentBuild, _, err = k.GetStringValue("CurrentBuild"); err != nil {
+        return v, err
+    }
+    if v.EditionID, _, err = k.GetStringValue("EditionID"); err != nil {
+        return v, err
+    }
+
+    // DisplayVersion and UBR are missing on older releases.
+    v.DisplayVersion, _, err = k.GetStringValue("DisplayVersion")
+    if err != nil && err != registry.ErrNotExist {
+        return v, err
+    }
+    ubr, _, err := k.GetIntegerValue("UBR")
+    if err != nil && err != registry.ErrNotExist {
+        return v, err
+    }
+    v.UBR = uint32(ubr)
+
+    return v, nil
+}
+
+// ReadInstallDate returns when Windows was installed. The QWORD FILETIME in
+// InstallTime, present on newer releases, is preferred for its precision;
+// otherwise the DWORD Unix timestamp in InstallDate is used, which has a
+// resolution of one second.
+func ReadInstallDate() (time.Time, error) {
+    k, err := openKey(registry.LOCAL_MACHINE, currentVersionPath, registry.QUERY_VALUE)
+    if err != nil {
+        return time.Time{}, err
+    }
+    defer k.Close()
+
+    ft, _, err := k.GetIntegerValue("InstallTime")
+    if err == nil {
+        f := windows.Filetime{LowDateTime: uint32(ft), HighDateTime: uint32(ft >> 32)}
+        return time.Unix(0, f.Nanoseconds()), nil
+    }
+    if err != registry.ErrNotExist {
+        return time.Time{}, err
+    }
+
+    secs, _, err := k.GetIntegerValue("InstallDate")
+    if err != nil {
+        return time.Time{}, err
+    }
+    return time.Unix(int64(secs), 0), nil
+}
@@ -0,0 +1,76 @@
+package winreg
+
+import (
+    "errors"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// ErrTxDone is returned when a Transaction is used after Commit or Rollback.
+var ErrTxDone = errors.New("winreg: transaction has already been committed or rolled back")
+
+// Transaction groups registry writes so that they take effect atomically.
+// Writes made through a transaction are not visible to other readers until
+// Commit is called, and are discarded by Rollback.
+type Transaction struct {
+    h windows.Handle
+}
+
+// BeginTransaction starts a new registry transaction. Either Commit or
+// Rollback must be called to release it.
+func BeginTransaction() (*Transaction, error) {
+    h, err := createTransaction()
+    if err != nil {
+        return nil, err
+    }
+    return &Transaction{h: h}, nil
+}
+
+// Commit makes every write made through the transaction visible at once.
+func (tx *Transaction) Commit() error {
+    if tx.h == 0 {
+        return ErrTxDone
+    }
+    err := commitTransaction(tx.h)
+    windows.CloseHandle(tx.h)
+    tx.h = 0
+    return err
+}
+
+// Rollback discards every write made through the transaction.
+func (tx *Transaction) Rollback() error {
+    if tx.h == 0 {
+        return ErrTxDone
+    }
+    err := rollbackTransaction(tx.h)
+    windows.CloseHandle(tx.h)
+    tx.h = 0
+    return err
+}
+
+// OpenKey opens a key as part of the transaction. Changes made through the
+// returned key belong to the transaction. The caller must close the key.
+func (tx *Transaction) OpenKey(root registry.Key, keyPath string, access uint32) (registry.Key, error) {
+    if tx.h == 0 {
+        return 0, ErrTxDone
+    }
+    return regOpenKeyTransacted(root, keyPath, access, tx.h)
+}
+
+// WriteDWordValueTx writes a DWORD value as part of tx. With a nil tx it
+// behaves like WriteDWordValue.
+func WriteDWordValueTx(tx *Transaction, root registry.Key, keyPath, valueName string, data uint32) (err error) {
+    if tx == nil {
+        return WriteDWordValue(root, keyPath, valueName, data)
+    }
+    defer auditValue("WriteDWordValueTx", root, keyPath, valueName, data)(&err)
+
+    k, err := tx.OpenKey(root, keyPath, registry.WRITE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return k.SetDWordValue(valueName, data)
+}
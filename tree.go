@@ -0,0 +1,337 @@
+package winreg
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// Node is an in-memory copy of a registry key, its values and its subkeys.
+// The default value of a key is stored under the empty name.
+type Node struct {
+    Name     string
+    Values   map[string]TypedValue
+    Children []*Node
+}
+
+// Child returns the direct child with the given name, or nil. Names are
+// compared case-insensitively, as the registry does.
+func (n *Node) Child(name string) *Node {
+    for _, c := range n.Children {
+        if strings.EqualFold(c.Name, name) {
+            return c
+        }
+    }
+    return nil
+}
+
+// ReadTree reads the key at keyPath and everything beneath it into a Node.
+func ReadTree(root registry.Key, keyPath string) (*Node, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    name := cleanPath(keyPath)
+    if p := parentPath(name); p != "" {
+        name = name[len(p)+1:]
+    }
+
+    return readNode(k, name)
+}
+
+// readNode reads the open key k into a Node called name.
+func readNode(k registry.Key, name string) (*Node, error) {
+    n := &Node{Name: name, Values: make(map[string]TypedValue)}
+
+    valueNames, err := k.ReadValueNames(-1)
+    if err != nil {
+        return nil, err
+    }
+    for _, vn := range valueNames {
+        v, err := readTypedValue(k, vn)
+        if err != nil {
+            return nil, err
+        }
+        n.Values[vn] = v
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return nil, err
+    }
+    for _, sk := range subKeys {
+        ck, err := openKey(k, sk, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+        if err != nil {
+            return nil, err
+        }
+        child, err := readNode(ck, sk)
+        ck.Close()
+        if err != nil {
+            return nil, err
+        }
+        n.Children = append(n.Children, child)
+    }
+
+    return n, nil
+}
+
+// WriteTree creates the key at keyPath and writes node's values and children
+// beneath it, preserving each value's type. The node's own Name is ignored.
+// When prune is true, values and subkeys that are not present in the tree
+// are deleted so the registry exactly mirrors node.
+//
+// A TypedValue whose Raw field is set is written from Raw, so clear Raw when
+// editing Data in a tree obtained from ReadTree.
+func WriteTree(root registry.Key, keyPath string, node *Node, prune bool) error {
+    keyPath = cleanPath(keyPath)
+    k, err := createKeyAudited("WriteTree", root, keyPath, treeAccess)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return writeNode(root, keyPath, k, node, prune)
+}
+
+// treeAccess is the access needed on each key touched by WriteTree.
+const treeAccess = registry.QUERY_VALUE | registry.SET_VALUE | registry.CREATE_SUB_KEY | registry.ENUMERATE_SUB_KEYS
+
+// writeNode writes node's values and children into the open key k, known as
+// keyPath under root.
+func writeNode(root registry.Key, keyPath string, k registry.Key, node *Node, prune bool) error {
+    for name, v := range node.Values {
+        err := func() (err error) {
+            defer auditValue("WriteTree", root, keyPath, name, v)(&err)
+            return writeTypedValue(k, name, v)
+        }()
+        if err != nil {
+            return fmt.Errorf("winreg: writing value %q: %w", name, err)
+        }
+    }
+
+    if prune {
+        wanted := make(map[string]bool, len(node.Values))
+        for name := range node.Values {
+            wanted[strings.ToLower(name)] = true
+        }
+        existing, err := k.ReadValueNames(-1)
+        if err != nil {
+            return err
+        }
+        for _, name := range existing {
+            if !wanted[strings.ToLower(name)] {
+                if err := deleteValueAudited("WriteTree", root, keyPath, k, name); err != nil {
+                    return err
+                }
+            }
+        }
+
+        subKeys, err := k.ReadSubKeyNames(-1)
+        if err != nil {
+            return err
+        }
+        for _, name := range subKeys {
+            if node.Child(name) == nil {
+                err := func() (err error) {
+                    defer auditKey("WriteTree", root, joinKeyPath(keyPath, name))(&err)
+                    return deleteKeyTree(k, name)
+                }()
+                if err != nil {
+                    return err
+                }
+            }
+        }
+    }
+
+    for _, child := range node.Children {
+        childPath := joinKeyPath(keyPath, child.Name)
+        ck, err := createKeyAudited("WriteTree", root, childPath, treeAccess)
+        if err != nil {
+            return err
+        }
+        err = writeNode(root, childPath, ck, child, prune)
+        ck.Close()
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// errDeleteRoot is returned instead of deleting everything under a root key
+// when a key path to delete is empty.
+var errDeleteRoot = errors.New("winreg: refusing to delete a root key")
+
+// deleteKeyTreeAt deletes the key at keyPath together with everything
+// beneath it. An empty keyPath is rejected rather than emptying root.
+func deleteKeyTreeAt(root registry.Key, keyPath string) error {
+    keyPath = cleanPath(keyPath)
+    if keyPath == "" {
+        return errDeleteRoot
+    }
+    parent, err := openKey(root, parentPath(keyPath), registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return err
+    }
+    defer parent.Close()
+
+    return deleteKeyTree(parent, keyPath[strings.LastIndex(keyPath, `\`)+1:])
+}
+
+// deleteKeyTree deletes the subkey name of the open key parent together with
+// everything beneath it. registry.DeleteKey fails on keys that have subkeys.
+// A symbolic link key is deleted itself; its target is left alone.
+func deleteKeyTree(parent registry.Key, name string) error {
+    if name == "" {
+        return errDeleteRoot
+    }
+    k, err := openKeyLink(parent, name, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE|keyDelete)
+    if err != nil {
+        return err
+    }
+    link, err := isLinkKey(k)
+    if err == nil && link {
+        err = ntDeleteKey(k)
+        k.Close()
+        return err
+    }
+    var subKeys []string
+    if err == nil {
+        subKeys, err = k.ReadSubKeyNames(-1)
+    }
+    if err == nil {
+        for _, sk := range subKeys {
+            if err = deleteKeyTree(k, sk); err != nil {
+                break
+            }
+        }
+    }
+    k.Close()
+    if err != nil {
+        return err
+    }
+
+    return registry.DeleteKey(parent, name)
+}
+
+// RenameSubKey renames the subkey oldName of the key at parentPath to
+// newName. The registry cannot rename keys in place, so the subtree is
+// copied, the copy is verified against the original with HashSubtree, and
+// only then is the original deleted. If the copy does not match it is
+// removed again and the original is left untouched. newName must not exist.
+func RenameSubKey(root registry.Key, parentPath, oldName, newName string) (err error) {
+    oldPath, newPath := joinKeyPath(cleanPath(parentPath), oldName), joinKeyPath(cleanPath(parentPath), newName)
+    defer auditKey("RenameSubKey", root, oldPath)(&err)
+
+    if KeyExists(root, newPath) {
+        return fmt.Errorf("winreg: cannot rename %s: %s already exists", oldPath, newPath)
+    }
+
+    node, err := ReadTree(root, oldPath)
+    if err != nil {
+        return err
+    }
+    if err := copyAndVerify(root, oldPath, newPath, node); err != nil {
+        if cleanupErr := deleteKeyTreeAt(root, newPath); cleanupErr != nil && cleanupErr != registry.ErrNotExist {
+            return errors.Join(err, fmt.Errorf("winreg: removing %s: %w", newPath, cleanupErr))
+        }
+        return err
+    }
+
+    return deleteKeyTreeAt(root, oldPath)
+}
+
+// copyAndVerify writes node to newPath and checks that the copy hashes the
+// same as the original at oldPath.
+func copyAndVerify(root registry.Key, oldPath, newPath string, node *Node) error {
+    if err := WriteTree(root, newPath, node, false); err != nil {
+        return err
+    }
+
+    want, err := HashSubtree(root, oldPath)
+    if err != nil {
+        return err
+    }
+    got, err := HashSubtree(root, newPath)
+    if err != nil {
+        return err
+    }
+    if !bytes.Equal(got, want) {
+        return fmt.Errorf("winreg: cannot rename %s: copy does not match the original", oldPath)
+    }
+    return nil
+}
+
+// DeleteEmptySubKeys deletes the direct subkeys of the key at keyPath that
+// have neither values nor subkeys, and returns how many were deleted.
+func DeleteEmptySubKeys(root registry.Key, keyPath string) (int, error) {
+    return deleteEmptySubKeys(root, keyPath, false)
+}
+
+// PruneEmptySubKeys is like DeleteEmptySubKeys but works bottom-up through
+// the whole subtree, so keys that only become empty once their empty
+// children are gone are deleted as well. The key at keyPath itself is kept.
+func PruneEmptySubKeys(root registry.Key, keyPath string) (int, error) {
+    return deleteEmptySubKeys(root, keyPath, true)
+}
+
+func deleteEmptySubKeys(root registry.Key, keyPath string, recursive bool) (int, error) {
+    k, err := openKey(root, keyPath, registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return 0, err
+    }
+    defer k.Close()
+
+    return deleteEmptyChildren(root, k, cleanPath(keyPath), recursive)
+}
+
+// deleteEmptyChildren deletes the empty subkeys of the open key k, known as
+// keyPath, descending first if recursive is set.
+func deleteEmptyChildren(root, k registry.Key, keyPath string, recursive bool) (int, error) {
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return 0, err
+    }
+
+    deleted := 0
+    for _, name := range subKeys {
+        path := joinKeyPath(keyPath, name)
+        sk, err := openKey(k, name, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+        if err != nil {
+            return deleted, err
+        }
+        if recursive {
+            n, err := deleteEmptyChildren(root, sk, path, true)
+            deleted += n
+            if err != nil {
+                sk.Close()
+                return deleted, err
+            }
+        }
+        info, err := sk.Stat()
+        sk.Close()
+        if err != nil {
+            return deleted, err
+        }
+        if info.SubKeyCount > 0 || info.ValueCount > 0 {
+            continue
+        }
+
+        err = func() (err error) {
+            defer auditKey("DeleteEmptySubKeys", root, path)(&err)
+            return registry.DeleteKey(k, name)
+        }()
+        if err != nil {
+            return deleted, err
+        }
+        deleted++
+    }
+
+    return deleted, nil
+}
@@ -0,0 +1,81 @@
+package winreg
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// ErrHiveNotLoaded is returned when a user's hive is not mounted under
+// HKEY_USERS, typically because the user is not logged on.
+var ErrHiveNotLoaded = errors.New("winreg: user hive not loaded")
+
+// OpenUserHive resolves username (e.g. "alice" or `DOMAIN\alice`) to its SID
+// and checks that the user's hive is loaded under HKEY_USERS. It returns
+// registry.USERS together with the SID, which is the path prefix for that
+// user's keys:
+//
+//    root, sid, err := OpenUserHive("alice")
+//    v, err := ReadDWordValue(root, sid+`\Control Panel\Desktop`, "WheelScrollLines")
+//
+// If the hive isn't loaded the SID is still returned along with
+// ErrHiveNotLoaded.
+func OpenUserHive(username string) (registry.Key, string, error) {
+    sid, _, _, err := windows.LookupSID("", username)
+    if err != nil {
+        return 0, "", err
+    }
+    s := sid.String()
+
+    if !KeyExists(registry.USERS, s) {
+        return 0, s, ErrHiveNotLoaded
+    }
+
+    return registry.USERS, s, nil
+}
+
+// systemSIDs are the service accounts whose hives are always loaded under
+// HKEY_USERS: LocalSystem, LocalService and NetworkService.
+var systemSIDs = map[string]bool{
+    "S-1-5-18": true,
+    "S-1-5-19": true,
+    "S-1-5-20": true,
+}
+
+// ForEachUserHive calls fn for every user hive loaded under HKEY_USERS,
+// skipping .DEFAULT, the service accounts and the separate _Classes hives.
+// prefix is the path to prepend to keys of that user for use with
+// registry.USERS, e.g. prefix+`\Control Panel\Desktop`. An error from fn
+// does not stop the loop; all errors are returned together, joined with
+// errors.Join and naming the SID concerned.
+func ForEachUserHive(fn func(sid string, prefix string) error) error {
+    sids, err := EnumerateSubKeys(registry.USERS, "")
+    if err != nil {
+        return err
+    }
+
+    var errs []error
+    for _, sid := range sids {
+        if !strings.HasPrefix(sid, "S-") || strings.HasSuffix(sid, "_Classes") || systemSIDs[sid] {
+            continue
+        }
+        if err := fn(sid, sid); err != nil {
+            errs = append(errs, fmt.Errorf("winreg: user %s: %w", sid, err))
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+// CurrentUserKey opens the hive of the user the calling thread runs as, with
+// whatever access that user is granted. Unlike registry.CURRENT_USER, whose
+// handle Windows caches per process, it honours impersonation, so inside a
+// service impersonating a user it yields that user's hive rather than the
+// one of LocalSystem. The key can be passed as root to the other functions
+// of this package and must be closed by the caller.
+func CurrentUserKey() (registry.Key, error) {
+    return regOpenCurrentUser(maximumAllowed)
+}
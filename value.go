@@ -0,0 +1,296 @@
+package winreg
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "syscall"
+    "unicode/utf16"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// errNullInString is returned when string data to be written contains a null
+// character, which would silently truncate it.
+var errNullInString = errors.New("winreg: string value contains a null character")
+
+// TypedValue carries a registry value together with its type. Data holds the
+// decoded form and Raw the bytes exactly as stored in the registry.
+//
+// Data is a string for SZ, EXPAND_SZ and LINK, a []string for MULTI_SZ, a
+// uint32 for DWORD and DWORD_BIG_ENDIAN, a uint64 for QWORD and a []byte for
+// every other type or for integer values whose size is malformed.
+type TypedValue struct {
+    Type uint32
+    Data any
+    Raw  []byte
+}
+
+// ReadTypedValue reads a value of any type together with its decoded form
+// and raw bytes.
+func ReadTypedValue(root registry.Key, keyPath, valueName string) (TypedValue, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return TypedValue{}, err
+    }
+    defer k.Close()
+
+    return readTypedValue(k, valueName)
+}
+
+// readRawValue reads the bytes and type of a value from an open key.
+func readRawValue(k registry.Key, valueName string) ([]byte, uint32, error) {
+    buf := make([]byte, 64)
+    for {
+        n, typ, err := k.GetValue(valueName, buf)
+        if err == nil {
+            return buf[:n], typ, nil
+        }
+        if err != syscall.ERROR_MORE_DATA || n <= len(buf) {
+            return nil, typ, err
+        }
+        buf = make([]byte, n)
+    }
+}
+
+// readTypedValue reads a value from an open key into a TypedValue.
+func readTypedValue(k registry.Key, valueName string) (TypedValue, error) {
+    raw, typ, err := readRawValue(k, valueName)
+    if err != nil {
+        return TypedValue{}, err
+    }
+    return TypedValue{Type: typ, Data: decodeValue(typ, raw), Raw: raw}, nil
+}
+
+// decodeValue converts raw value bytes to their Go form as documented on
+// TypedValue.
+func decodeValue(typ uint32, raw []byte) any {
+    switch typ {
+    case registry.SZ, registry.EXPAND_SZ, registry.LINK:
+        return decodeString(raw)
+    case registry.MULTI_SZ:
+        return decodeStrings(raw)
+    case registry.DWORD:
+        if len(raw) == 4 {
+            return binary.LittleEndian.Uint32(raw)
+        }
+    case registry.DWORD_BIG_ENDIAN:
+        if len(raw) == 4 {
+            return binary.BigEndian.Uint32(raw)
+        }
+    case registry.QWORD:
+        if len(raw) == 8 {
+            return binary.LittleEndian.Uint64(raw)
+        }
+    }
+    return raw
+}
+
+// bytesToUTF16 reinterprets little-endian bytes as UTF-16 code units,
+// dropping a trailing odd byte.
+func bytesToUTF16(raw []byte) []uint16 {
+    u := make([]uint16, len(raw)/2)
+    for i := range u {
+        u[i] = binary.LittleEndian.Uint16(raw[2*i:])
+    }
+    return u
+}
+
+// decodeString decodes UTF-16 string data up to the first null, whether or
+// not the data is null terminated.
+func decodeString(raw []byte) string {
+    u := bytesToUTF16(raw)
+    for i, c := range u {
+        if c == 0 {
+            u = u[:i]
+            break
+        }
+    }
+    return string(utf16.Decode(u))
+}
+
+// decodeStrings decodes MULTI_SZ data into its component strings.
+func decodeStrings(raw []byte) []string {
+    u := bytesToUTF16(raw)
+    for len(u) > 0 && u[len(u)-1] == 0 {
+        u = u[:len(u)-1]
+    }
+    if len(u) == 0 {
+        return nil
+    }
+
+    var val []string
+    from := 0
+    for i, c := range u {
+        if c == 0 {
+            val = append(val, string(utf16.Decode(u[from:i])))
+            from = i + 1
+        }
+    }
+    return append(val, string(utf16.Decode(u[from:])))
+}
+
+// WriteTypedValue writes v with type v.Type. If v.Raw is set it is written
+// as-is; otherwise v.Data is encoded, and must have the Go type documented on
+// TypedValue for v.Type or an error is returned.
+func WriteTypedValue(root registry.Key, keyPath, valueName string, v TypedValue) (err error) {
+    defer auditValue("WriteTypedValue", root, keyPath, valueName, v)(&err)
+
+    k, err := openKey(root, keyPath, registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    return writeTypedValue(k, valueName, v)
+}
+
+// WriteTypedValues writes each of values as WriteTypedValue does, opening the
+// key only once. The values are written in name order and a failure does not
+// stop the others; the failures are returned together, joined with
+// errors.Join and naming the value concerned. Values written successfully
+// stay written.
+func WriteTypedValues(root registry.Key, keyPath string, values map[string]TypedValue) error {
+    k, err := openKey(root, keyPath, registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    names := make([]string, 0, len(values))
+    for name := range values {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var errs []error
+    for _, name := range names {
+        err := func() (err error) {
+            defer auditValue("WriteTypedValues", root, keyPath, name, values[name])(&err)
+            return writeTypedValue(k, name, values[name])
+        }()
+        if err != nil {
+            errs = append(errs, fmt.Errorf("winreg: writing value %q: %w", name, err))
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+// writeTypedValue stores v under valueName in the open key k. Raw is written
+// as-is when set; otherwise Data is encoded according to Type.
+func writeTypedValue(k registry.Key, valueName string, v TypedValue) error {
+    raw := v.Raw
+    if raw == nil {
+        var err error
+        if raw, err = encodeValue(v.Type, v.Data); err != nil {
+            return err
+        }
+    }
+    return regSetValueEx(k, valueName, v.Type, raw)
+}
+
+// encodeValue converts data to the bytes stored for a value of type typ. The
+// Go type of data must match the one documented on TypedValue.
+func encodeValue(typ uint32, data any) ([]byte, error) {
+    switch typ {
+    case registry.SZ, registry.EXPAND_SZ, registry.LINK:
+        s, ok := data.(string)
+        if !ok {
+            break
+        }
+        if strings.IndexByte(s, 0) >= 0 {
+            return nil, errNullInString
+        }
+        if typ == registry.LINK {
+            // Link targets are stored without a terminating null.
+            return encodeUTF16(s), nil
+        }
+        return encodeUTF16(s + "\x00"), nil
+    case registry.MULTI_SZ:
+        ss, ok := data.([]string)
+        if !ok {
+            break
+        }
+        var b strings.Builder
+        for _, s := range ss {
+            if strings.IndexByte(s, 0) >= 0 {
+                return nil, errNullInString
+            }
+            b.WriteString(s)
+            b.WriteByte(0)
+        }
+        b.WriteByte(0)
+        return encodeUTF16(b.String()), nil
+    case registry.DWORD:
+        if v, ok := data.(uint32); ok {
+            return binary.LittleEndian.AppendUint32(nil, v), nil
+        }
+    case registry.DWORD_BIG_ENDIAN:
+        if v, ok := data.(uint32); ok {
+            return binary.BigEndian.AppendUint32(nil, v), nil
+        }
+    case registry.QWORD:
+        if v, ok := data.(uint64); ok {
+            return binary.LittleEndian.AppendUint64(nil, v), nil
+        }
+    default:
+        if v, ok := data.([]byte); ok {
+            return v, nil
+        }
+    }
+    return nil, fmt.Errorf("winreg: cannot store %T as %s", data, TypeName(typ))
+}
+
+// encodeUTF16 encodes s as little-endian UTF-16 bytes.
+func encodeUTF16(s string) []byte {
+    u := utf16.Encode([]rune(s))
+    b := make([]byte, 2*len(u))
+    for i, c := range u {
+        binary.LittleEndian.PutUint16(b[2*i:], c)
+    }
+    return b
+}
+
+// typeNames maps value types to their REG_* names.
+var typeNames = map[uint32]string{
+    registry.NONE:                       "REG_NONE",
+    registry.SZ:                         "REG_SZ",
+    registry.EXPAND_SZ:                  "REG_EXPAND_SZ",
+    registry.BINARY:                     "REG_BINARY",
+    registry.DWORD:                      "REG_DWORD",
+    registry.DWORD_BIG_ENDIAN:           "REG_DWORD_BIG_ENDIAN",
+    registry.LINK:                       "REG_LINK",
+    registry.MULTI_SZ:                   "REG_MULTI_SZ",
+    registry.RESOURCE_LIST:              "REG_RESOURCE_LIST",
+    registry.FULL_RESOURCE_DESCRIPTOR:   "REG_FULL_RESOURCE_DESCRIPTOR",
+    registry.RESOURCE_REQUIREMENTS_LIST: "REG_RESOURCE_REQUIREMENTS_LIST",
+    registry.QWORD:                      "REG_QWORD",
+}
+
+// TypeName returns the name of a value type constant, such as "REG_SZ" for
+// registry.SZ. Unknown types are formatted as "REG_" followed by the number.
+func TypeName(typ uint32) string {
+    if name, ok := typeNames[typ]; ok {
+        return name
+    }
+    return fmt.Sprintf("REG_%d", typ)
+}
+
+// parseTypeName is the inverse of TypeName.
+func parseTypeName(name string) (uint32, bool) {
+    for typ, n := range typeNames {
+        if n == name {
+            return typ, true
+        }
+    }
+    if rest, ok := strings.CutPrefix(name, "REG_"); ok {
+        if typ, err := strconv.ParseUint(rest, 10, 32); err == nil {
+            return uint32(typ), true
+        }
+    }
+    return 0, false
+}
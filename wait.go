@@ -0,0 +1,63 @@
+package winreg
+
+import (
+    "context"
+    "time"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// ReadDWordValueWait reads a DWORD value that may not exist yet, for example
+// because another process is still creating it during first-run setup. While
+// the key or the value is missing it retries every poll interval until the
+// value appears or ctx is done, in which case ctx.Err() is returned. Errors
+// other than registry.ErrNotExist are returned immediately.
+func ReadDWordValueWait(ctx context.Context, root registry.Key, keyPath, valueName string, poll time.Duration) (uint32, error) {
+    t := time.NewTicker(poll)
+    defer t.Stop()
+
+    for {
+        v, err := ReadDWordValue(root, keyPath, valueName)
+        if err != registry.ErrNotExist {
+            return v, err
+        }
+
+        select {
+        case <-ctx.Done():
+            return 0, ctx.Err()
+        case <-t.C:
+        }
+    }
+}
+
+// ReadStringValueContext is ReadStringValue that gives up when ctx is done.
+// Registry calls cannot be interrupted, so a call that hangs, for example on
+// an unresponsive remote hive, keeps running in the background and its
+// result is discarded; the caller gets ctx.Err() right away.
+func ReadStringValueContext(ctx context.Context, root registry.Key, keyPath, valueName string) (string, error) {
+    type result struct {
+        s   string
+        err error
+    }
+    done := make(chan result, 1)
+    go func() {
+        s, err := ReadStringValue(root, keyPath, valueName)
+        done <- result{s, err}
+    }()
+
+    select {
+    case r := <-done:
+        return r.s, r.err
+    case <-ctx.Done():
+        return "", ctx.Err()
+    }
+}
+
+// ReadStringValueTimeout is ReadStringValueContext with a timeout instead of
+// a context. context.DeadlineExceeded is returned if the read takes longer.
+func ReadStringValueTimeout(root registry.Key, keyPath, valueName string, timeout time.Duration) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+
+    return ReadStringValueContext(ctx, root, keyPath, valueName)
+}
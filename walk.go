@@ -0,0 +1,119 @@
+package winreg
+
+import (
+    "errors"
+    "strings"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// SkipKey can be returned by a walk callback to skip the subkeys of the key
+// it was called for. The walk continues with the key's siblings.
+var SkipKey = errors.New("winreg: skip this key")
+
+// WalkKeys calls fn for the key at keyPath and every key beneath it, parents
+// before children. The path passed to fn is relative to root, so it can be
+// used with the other functions of this package. If fn returns an error
+// other than SkipKey the walk stops and returns it.
+func WalkKeys(root registry.Key, keyPath string, fn func(path string) error) error {
+    return WalkKeysDepth(root, keyPath, -1, func(path string, depth int) error {
+        return fn(path)
+    })
+}
+
+// WalkKeysDepth is like WalkKeys but does not descend below maxDepth levels
+// under keyPath and passes each key's depth to fn. keyPath itself has depth
+// 0 and its direct subkeys depth 1. A negative maxDepth means no limit.
+func WalkKeysDepth(root registry.Key, keyPath string, maxDepth int, fn func(path string, depth int) error) error {
+    return WalkKeysWith(root, keyPath, WalkOptions{LimitDepth: maxDepth >= 0, MaxDepth: maxDepth}, fn)
+}
+
+// WalkOptions controls WalkKeysWith. The zero value walks the whole
+// subtree.
+type WalkOptions struct {
+    // LimitDepth enables MaxDepth. Without it the walk has no depth limit.
+    LimitDepth bool
+
+    // MaxDepth limits the walk as described on WalkKeysDepth when
+    // LimitDepth is set. Zero then means only the starting key.
+    MaxDepth int
+
+    // SkipRevisits makes the walk remember the NT path of every key it
+    // enters and skip keys it has already visited under another path, as
+    // happens when a symbolic link key points into the walked subtree.
+    // Skipped keys are not passed to fn.
+    SkipRevisits bool
+
+    // OnSkip, if set, is called with the path of every key skipped because
+    // of SkipRevisits and the NT path it resolved to.
+    OnSkip func(path, ntPath string)
+}
+
+// WalkKeysWith is WalkKeysDepth with further options.
+func WalkKeysWith(root registry.Key, keyPath string, opts WalkOptions, fn func(path string, depth int) error) error {
+    k, err := openKey(root, keyPath, registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    w := &walker{opts: opts, fn: fn}
+    if opts.SkipRevisits {
+        w.visited = make(map[string]bool)
+    }
+
+    err = w.walk(k, cleanPath(keyPath), 0)
+    if err == SkipKey {
+        return nil
+    }
+    return err
+}
+
+// walker holds the state of a single walk.
+type walker struct {
+    opts    WalkOptions
+    fn      func(path string, depth int) error
+    visited map[string]bool // lower-cased NT paths; nil unless SkipRevisits
+}
+
+// walk visits the open key k, known as path, and its subkeys.
+func (w *walker) walk(k registry.Key, path string, depth int) error {
+    if w.visited != nil {
+        ntPath, err := ntQueryKeyName(k)
+        if err != nil {
+            return err
+        }
+        if w.visited[strings.ToLower(ntPath)] {
+            if w.opts.OnSkip != nil {
+                w.opts.OnSkip(path, ntPath)
+            }
+            return nil
+        }
+        w.visited[strings.ToLower(ntPath)] = true
+    }
+
+    if err := w.fn(path, depth); err != nil {
+        return err
+    }
+    if w.opts.LimitDepth && depth == w.opts.MaxDepth {
+        return nil
+    }
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return err
+    }
+    for _, name := range subKeys {
+        sk, err := openKey(k, name, registry.ENUMERATE_SUB_KEYS)
+        if err != nil {
+            return err
+        }
+        err = w.walk(sk, joinKeyPath(path, name), depth+1)
+        sk.Close()
+        if err != nil && err != SkipKey {
+            return err
+        }
+    }
+
+    return nil
+}
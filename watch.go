@@ -0,0 +1,202 @@
+package winreg
+
+import (
+    "bytes"
+    "context"
+    "sync"
+
+    "golang.org/x/sys/windows"
+    "golang.org/x/sys/windows/registry"
+)
+
+// watchFilter selects the changes that wake a watcher: subkeys added or
+// removed and values added, changed or removed.
+const watchFilter = windows.REG_NOTIFY_CHANGE_NAME | windows.REG_NOTIFY_CHANGE_LAST_SET | windows.REG_NOTIFY_THREAD_AGNOSTIC
+
+// OpenForNotify opens the key at keyPath with KEY_NOTIFY access and nothing
+// more, which is all RegNotifyChangeKeyValue needs. It works on keys whose
+// values the caller may not read, such as some policy keys, and is what the
+// watchers of this package use; it is exported for callers running their
+// own notification loop. The caller must close the returned key.
+func OpenForNotify(root registry.Key, keyPath string) (registry.Key, error) {
+    return openKey(root, keyPath, registry.NOTIFY)
+}
+
+// watchKey sends on the returned channel each time the open key k changes,
+// or anything beneath it when subtree is true. Notifications that arrive
+// while one is still pending are coalesced. watchKey takes ownership of k and
+// closes it, along with the channel, once ctx is done or the key can no
+// longer be watched, for example because it was deleted.
+func watchKey(ctx context.Context, k registry.Key, subtree bool) (<-chan struct{}, error) {
+    event, err := windows.CreateEvent(nil, 0, 0, nil)
+    if err != nil {
+        k.Close()
+        return nil, err
+    }
+    stop, err := windows.CreateEvent(nil, 1, 0, nil)
+    if err != nil {
+        windows.CloseHandle(event)
+        k.Close()
+        return nil, err
+    }
+    if err := windows.RegNotifyChangeKeyValue(windows.Handle(k), subtree, watchFilter, event, true); err != nil {
+        windows.CloseHandle(stop)
+        windows.CloseHandle(event)
+        k.Close()
+        return nil, err
+    }
+
+    changes := make(chan struct{}, 1)
+    exited := make(chan struct{})
+
+    go func() {
+        select {
+        case <-ctx.Done():
+            windows.SetEvent(stop)
+            <-exited
+        case <-exited:
+        }
+        windows.CloseHandle(stop)
+    }()
+
+    go func() {
+        defer close(exited)
+        defer close(changes)
+        defer windows.CloseHandle(event)
+        defer k.Close()
+
+        handles := []windows.Handle{event, stop}
+        for {
+            i, err := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+            if err != nil || i != windows.WAIT_OBJECT_0 {
+                return
+            }
+            select {
+            case changes <- struct{}{}:
+            default:
+            }
+            if err := windows.RegNotifyChangeKeyValue(windows.Handle(k), subtree, watchFilter, event, true); err != nil {
+                return
+            }
+        }
+    }()
+
+    return changes, nil
+}
+
+// WatchValue watches a single value and sends its decoded data, in the form
+// documented on TypedValue, every time it changes. Changes to other values
+// of the key are filtered out. If the value is deleted nil is sent. The
+// channel is closed once ctx is done or the key can no longer be watched.
+// Reading the value needs query access in addition to notify access; each
+// is requested on a handle of its own.
+func WatchValue(ctx context.Context, root registry.Key, keyPath, valueName string) (<-chan any, error) {
+    qk, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    nk, err := OpenForNotify(root, keyPath)
+    if err != nil {
+        qk.Close()
+        return nil, err
+    }
+
+    last, lastType, err := readRawValue(qk, valueName)
+    lastExists := err == nil
+
+    changes, err := watchKey(ctx, nk, false)
+    if err != nil {
+        qk.Close()
+        return nil, err
+    }
+
+    values := make(chan any)
+    go func() {
+        defer close(values)
+        defer qk.Close()
+
+        for range changes {
+            raw, typ, err := readRawValue(qk, valueName)
+            exists := err == nil
+            if err != nil && err != registry.ErrNotExist {
+                continue
+            }
+            if exists == lastExists && typ == lastType && bytes.Equal(raw, last) {
+                continue
+            }
+            last, lastType, lastExists = raw, typ, exists
+
+            var data any
+            if exists {
+                data = decodeValue(typ, raw)
+            }
+            select {
+            case values <- data:
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+
+    return values, nil
+}
+
+// WatchSpec names a key for WatchKeys. If Subtree is set, changes anywhere
+// beneath the key are reported too.
+type WatchSpec struct {
+    Root    registry.Key
+    Path    string
+    Subtree bool
+}
+
+// WatchEvent reports a change to one of the keys given to WatchKeys. Index
+// is the position of Spec in the slice passed to WatchKeys.
+type WatchEvent struct {
+    Index int
+    Spec  WatchSpec
+}
+
+// WatchKeys watches several keys and reports their changes on a single
+// channel. The keys are opened with OpenForNotify, so notify access to them
+// is sufficient. Changes that happen while an event for the same key is still
+// pending are coalesced. The channel is closed once ctx is done or none of
+// the keys can be watched any longer. If any key cannot be opened, no watch
+// is started and the error is returned.
+func WatchKeys(ctx context.Context, specs []WatchSpec) (<-chan WatchEvent, error) {
+    ctx, cancel := context.WithCancel(ctx)
+
+    sources := make([]<-chan struct{}, len(specs))
+    for i, spec := range specs {
+        k, err := OpenForNotify(spec.Root, spec.Path)
+        if err == nil {
+            sources[i], err = watchKey(ctx, k, spec.Subtree)
+        }
+        if err != nil {
+            cancel()
+            return nil, err
+        }
+    }
+
+    events := make(chan WatchEvent)
+    var wg sync.WaitGroup
+    for i, changes := range sources {
+        wg.Add(1)
+        go func(ev WatchEvent, changes <-chan struct{}) {
+            defer wg.Done()
+            for range changes {
+                select {
+                case events <- ev:
+                case <-ctx.Done():
+                    return
+                }
+            }
+        }(WatchEvent{Index: i, Spec: specs[i]}, changes)
+    }
+    go func() {
+        wg.Wait()
+        cancel()
+        close(events)
+    }()
+
+    return events, nil
+}
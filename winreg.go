@@ -1,14 +1,19 @@
 package winreg
 
 import (
+    "encoding/binary"
+    "errors"
     "fmt"
+    "math"
+    "sort"
+    "strings"
     "golang.org/x/sys/windows/registry"
 )
 
 
 // ReadDWordValue reads a DWORD value from the Windows Registry.
 func ReadDWordValue(root registry.Key, keyPath, valueName string) (uint32, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return 0, err
     }
@@ -23,8 +28,10 @@ func ReadDWordValue(root registry.Key, keyPath, valueName string) (uint32, error
 }
 
 // WriteDWordValue writes a DWORD value to the Windows Registry.
-func WriteDWordValue(root registry.Key, keyPath, valueName string, data uint32) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteDWordValue(root registry.Key, keyPath, valueName string, data uint32) (err error) {
+    defer auditValue("WriteDWordValue", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -37,9 +44,80 @@ func WriteDWordValue(root registry.Key, keyPath, valueName string, data uint32)
     return nil
 }
 
+// IncrementDWord adds delta to a DWORD value, treating a missing value as 0,
+// and returns the new value. The read and the write use the same open key,
+// but there is no lock across processes: two processes incrementing at the
+// same moment can still lose an update. An error is returned, and nothing
+// written, if the result would not fit in a DWORD.
+func IncrementDWord(root registry.Key, keyPath, valueName string, delta int64) (uint32, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return 0, err
+    }
+    defer k.Close()
+
+    var cur uint32
+    raw, typ, err := readRawValue(k, valueName)
+    switch {
+    case err == registry.ErrNotExist:
+    case err != nil:
+        return 0, err
+    case typ != registry.DWORD || len(raw) != 4:
+        return 0, registry.ErrUnexpectedType
+    default:
+        cur = binary.LittleEndian.Uint32(raw)
+    }
+
+    n := int64(cur) + delta
+    if n < 0 || n > math.MaxUint32 {
+        return 0, fmt.Errorf("winreg: incrementing %d by %d overflows a DWORD", cur, delta)
+    }
+
+    next := uint32(n)
+    if err := setAudited("IncrementDWord", root, keyPath, k, valueName, registry.DWORD, binary.LittleEndian.AppendUint32(nil, next), next); err != nil {
+        return 0, err
+    }
+    return next, nil
+}
+
+// WriteDWordValueBackup writes a DWORD value like WriteDWordValue and returns a
+// function that restores the previous state: the prior value, with its
+// original type, or no value at all if it didn't exist before.
+func WriteDWordValueBackup(root registry.Key, keyPath, valueName string, data uint32) (restore func() error, err error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    prev, prevType, err := readRawValue(k, valueName)
+    existed := err == nil
+    if err != nil && err != registry.ErrNotExist {
+        return nil, err
+    }
+
+    if err := WriteDWordValue(root, keyPath, valueName, data); err != nil {
+        return nil, err
+    }
+
+    restore = func() error {
+        if !existed {
+            return DeleteValue(root, keyPath, valueName)
+        }
+        k, err := openKey(root, keyPath, registry.SET_VALUE)
+        if err != nil {
+            return err
+        }
+        defer k.Close()
+        return setAudited("WriteDWordValueBackup", root, keyPath, k, valueName, prevType, prev, decodeValue(prevType, prev))
+    }
+
+    return restore, nil
+}
+
 // ReadBinaryValue reads a binary value from the Windows Registry.
 func ReadBinaryValue(root registry.Key, keyPath, valueName string) ([]byte, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return nil, err
     }
@@ -54,8 +132,10 @@ func ReadBinaryValue(root registry.Key, keyPath, valueName string) ([]byte, erro
 }
 
 // WriteBinaryValue writes a binary value to the Windows Registry.
-func WriteBinaryValue(root registry.Key, keyPath, valueName string, data []byte) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteBinaryValue(root registry.Key, keyPath, valueName string, data []byte) (err error) {
+    defer auditValue("WriteBinaryValue", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -69,8 +149,10 @@ func WriteBinaryValue(root registry.Key, keyPath, valueName string, data []byte)
 }
 
 // DeleteValue deletes a registry value.
-func DeleteValue(root registry.Key, keyPath, valueName string) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func DeleteValue(root registry.Key, keyPath, valueName string) (err error) {
+    defer auditValue("DeleteValue", root, keyPath, valueName, nil)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -83,9 +165,107 @@ func DeleteValue(root registry.Key, keyPath, valueName string) error {
     return nil
 }
 
+// DeleteValueIfExists deletes a registry value if it exists. deleted
+// reports whether there was a value to delete; a missing value or key is
+// not an error. Because the deletion is attempted directly, there is no
+// window between checking for the value and deleting it.
+func DeleteValueIfExists(root registry.Key, keyPath, valueName string) (deleted bool, err error) {
+    defer auditValue("DeleteValueIfExists", root, keyPath, valueName, nil)(&err)
+
+    k, err := openKey(root, keyPath, registry.SET_VALUE)
+    if err == registry.ErrNotExist {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    defer k.Close()
+
+    err = k.DeleteValue(valueName)
+    if err == registry.ErrNotExist {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
+// EmptyValue resets a value to the empty form of its current type instead of
+// deleting it: an empty string for SZ and EXPAND_SZ, an empty list for
+// MULTI_SZ, zero for the integer types and zero-length data otherwise.
+func EmptyValue(root registry.Key, keyPath, valueName string) error {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return err
+    }
+    defer k.Close()
+
+    _, typ, err := k.GetValue(valueName, nil)
+    if err != nil {
+        return err
+    }
+
+    var data any = []byte{}
+    switch typ {
+    case registry.SZ, registry.EXPAND_SZ:
+        data = ""
+    case registry.MULTI_SZ:
+        data = []string{}
+    case registry.DWORD, registry.DWORD_BIG_ENDIAN:
+        data = uint32(0)
+    case registry.QWORD:
+        data = uint64(0)
+    }
+    raw, err := encodeValue(typ, data)
+    if err != nil {
+        return err
+    }
+
+    return setAudited("EmptyValue", root, keyPath, k, valueName, typ, raw, data)
+}
+
+// DeleteValueAndPrune deletes a registry value and then removes each parent
+// key, starting with keyPath itself, that is left without values or subkeys.
+// Pruning stops before stopAtPath, which must be keyPath or one of its ancestors.
+func DeleteValueAndPrune(root registry.Key, keyPath, valueName, stopAtPath string) error {
+    if !hasPathPrefix(keyPath, stopAtPath) {
+        return fmt.Errorf("winreg: %q is not under %q", keyPath, stopAtPath)
+    }
+
+    if err := DeleteValue(root, keyPath, valueName); err != nil {
+        return err
+    }
+
+    stop := cleanPath(stopAtPath)
+    for path := cleanPath(keyPath); path != "" && !strings.EqualFold(path, stop); path = parentPath(path) {
+        k, err := openKey(root, path, registry.QUERY_VALUE)
+        if err != nil {
+            return err
+        }
+        info, err := k.Stat()
+        k.Close()
+        if err != nil {
+            return err
+        }
+
+        if info.SubKeyCount > 0 || info.ValueCount > 0 {
+            return nil
+        }
+        if err := DeleteKey(root, path); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
 // DeleteSubKey deletes a registry subkey and all its subkeys and values.
-func DeleteSubKey(root registry.Key, keyPath, subKeyName string) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func DeleteSubKey(root registry.Key, keyPath, subKeyName string) (err error) {
+    defer auditKey("DeleteSubKey", root, keyPath+`\`+subKeyName)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -98,9 +278,62 @@ func DeleteSubKey(root registry.Key, keyPath, subKeyName string) error {
     return nil
 }
 
+// DeleteSubKeyIfExists is DeleteSubKey that tolerates a missing subkey or
+// parent key. deleted reports whether there was a subkey to delete. Like
+// DeleteSubKey it deletes only a subkey that has no subkeys of its own, and
+// an empty subKeyName is rejected rather than taken to mean keyPath itself.
+func DeleteSubKeyIfExists(root registry.Key, keyPath, subKeyName string) (deleted bool, err error) {
+    if subKeyName == "" {
+        return false, errors.New("winreg: empty subkey name")
+    }
+
+    defer auditKey("DeleteSubKeyIfExists", root, joinKeyPath(keyPath, subKeyName))(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
+    if err == registry.ErrNotExist {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    defer k.Close()
+
+    err = registry.DeleteKey(k, subKeyName)
+    if err == registry.ErrNotExist {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
 // Check if a registry key exists.
 func KeyExists(root registry.Key, keyPath string) bool {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return false
+    }
+    k.Close()
+    return true
+}
+
+// CanWrite reports whether the caller may set values in the key, without
+// writing anything.
+func CanWrite(root registry.Key, keyPath string) bool {
+    return canOpen(root, keyPath, registry.SET_VALUE)
+}
+
+// CanCreateSubKey reports whether the caller may create subkeys under the key,
+// without creating anything.
+func CanCreateSubKey(root registry.Key, keyPath string) bool {
+    return canOpen(root, keyPath, registry.CREATE_SUB_KEY)
+}
+
+// canOpen reports whether the key can be opened with the given access.
+func canOpen(root registry.Key, keyPath string, access uint32) bool {
+    k, err := openKey(root, keyPath, access)
     if err != nil {
         return false
     }
@@ -110,19 +343,61 @@ func KeyExists(root registry.Key, keyPath string) bool {
 
 // Check if a registry value exists.
 func ValueExists(root registry.Key, keyPath, valueName string) bool {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return false
     }
     defer k.Close()
 
-    _, _, err = k.GetStringValue(valueName)
+    _, _, err = k.GetValue(valueName, nil)
     return err == nil
 }
 
+// ValuesExist reports which of the given value names exist in the key,
+// regardless of their type. The key is opened only once.
+func ValuesExist(root registry.Key, keyPath string, names []string) (map[string]bool, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    exist := make(map[string]bool, len(names))
+    for _, name := range names {
+        _, _, err := k.GetValue(name, nil)
+        switch err {
+        case nil:
+            exist[name] = true
+        case registry.ErrNotExist:
+            exist[name] = false
+        default:
+            return nil, err
+        }
+    }
+
+    return exist, nil
+}
+
+// ValueSize returns the size in bytes and the type of a value without reading
+// its data.
+func ValueSize(root registry.Key, keyPath, valueName string) (uint64, uint32, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return 0, 0, err
+    }
+    defer k.Close()
+
+    n, typ, err := k.GetValue(valueName, nil)
+    if err != nil {
+        return 0, 0, err
+    }
+
+    return uint64(n), typ, nil
+}
+
 // EnumerateSubKeys returns a list of subkeys under the given key.
 func EnumerateSubKeys(root registry.Key, keyPath string) ([]string, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.ENUMERATE_SUB_KEYS)
+    k, err := openKey(root, keyPath, registry.ENUMERATE_SUB_KEYS)
     if err != nil {
         return nil, err
     }
@@ -136,9 +411,75 @@ func EnumerateSubKeys(root registry.Key, keyPath string) ([]string, error) {
     return subKeys, nil
 }
 
+// EnumerateSubKeysSorted returns the subkeys under the given key sorted
+// case-insensitively. With natural set, runs of digits compare by numeric
+// value, so "Item2" sorts before "Item10".
+func EnumerateSubKeysSorted(root registry.Key, keyPath string, natural bool) ([]string, error) {
+    subKeys, err := EnumerateSubKeys(root, keyPath)
+    if err != nil {
+        return nil, err
+    }
+
+    less := func(a, b string) bool {
+        return strings.ToLower(a) < strings.ToLower(b)
+    }
+    if natural {
+        less = naturalLess
+    }
+    sort.SliceStable(subKeys, func(i, j int) bool {
+        return less(subKeys[i], subKeys[j])
+    })
+
+    return subKeys, nil
+}
+
+// naturalLess compares a and b case-insensitively, treating runs of digits as
+// numbers.
+func naturalLess(a, b string) bool {
+    a, b = strings.ToLower(a), strings.ToLower(b)
+    for a != "" && b != "" {
+        if isDigit(a[0]) && isDigit(b[0]) {
+            na, ra := splitDigits(a)
+            nb, rb := splitDigits(b)
+            // Compare numerically without overflow: strip leading zeros and
+            // compare by length first.
+            ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+            if len(ta) != len(tb) {
+                return len(ta) < len(tb)
+            }
+            if ta != tb {
+                return ta < tb
+            }
+            if len(na) != len(nb) {
+                return len(na) < len(nb)
+            }
+            a, b = ra, rb
+            continue
+        }
+        if a[0] != b[0] {
+            return a[0] < b[0]
+        }
+        a, b = a[1:], b[1:]
+    }
+    return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+    return '0' <= c && c <= '9'
+}
+
+// splitDigits splits s into its leading run of digits and the rest.
+func splitDigits(s string) (digits, rest string) {
+    i := 0
+    for i < len(s) && isDigit(s[i]) {
+        i++
+    }
+    return s[:i], s[i:]
+}
+
 // EnumerateValues returns a list of value names under the given key.
 func EnumerateValues(root registry.Key, keyPath string) ([]string, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return nil, err
     }
@@ -152,31 +493,258 @@ func EnumerateValues(root registry.Key, keyPath string) ([]string, error) {
     return valueNames, nil
 }
 
+// EnumerateValuesGlob returns the names of the values whose name matches
+// pattern, compared case-insensitively. In pattern '*' matches any run of
+// characters and '?' a single character; every other character matches
+// itself.
+func EnumerateValuesGlob(root registry.Key, keyPath, pattern string) ([]string, error) {
+    valueNames, err := EnumerateValues(root, keyPath)
+    if err != nil {
+        return nil, err
+    }
+
+    var matched []string
+    for _, name := range valueNames {
+        if matchGlob(pattern, name) {
+            matched = append(matched, name)
+        }
+    }
+
+    return matched, nil
+}
+
+// DeleteValuesGlob deletes the values whose name matches pattern, as
+// described on EnumerateValuesGlob, and returns how many were deleted. The
+// key is opened once for all deletions. An empty pattern is rejected so that
+// a missing argument can't be mistaken for a request to match everything.
+func DeleteValuesGlob(root registry.Key, keyPath, pattern string) (int, error) {
+    if pattern == "" {
+        return 0, errors.New("winreg: empty value name pattern")
+    }
+
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE|registry.SET_VALUE)
+    if err != nil {
+        return 0, err
+    }
+    defer k.Close()
+
+    valueNames, err := k.ReadValueNames(-1)
+    if err != nil {
+        return 0, err
+    }
+
+    deleted := 0
+    for _, name := range valueNames {
+        if !matchGlob(pattern, name) {
+            continue
+        }
+        err := func() (err error) {
+            defer auditValue("DeleteValuesGlob", root, keyPath, name, nil)(&err)
+            return k.DeleteValue(name)
+        }()
+        if err != nil {
+            return deleted, err
+        }
+        deleted++
+    }
+
+    return deleted, nil
+}
+
+// matchGlob reports whether name matches the '*' and '?' pattern, ignoring
+// case.
+func matchGlob(pattern, name string) bool {
+    p, n := []rune(strings.ToLower(pattern)), []rune(strings.ToLower(name))
+
+    // On a mismatch, backtrack to the last '*' and let it swallow one more
+    // character of name.
+    star, retry := -1, 0
+    i, j := 0, 0
+    for j < len(n) {
+        switch {
+        case i < len(p) && p[i] == '*':
+            star, retry = i, j
+            i++
+        case i < len(p) && (p[i] == '?' || p[i] == n[j]):
+            i++
+            j++
+        case star >= 0:
+            retry++
+            i, j = star+1, retry
+        default:
+            return false
+        }
+    }
+    for i < len(p) && p[i] == '*' {
+        i++
+    }
+    return i == len(p)
+}
+
+// ReadChildDefaults returns the default string value of each direct subkey,
+// keyed by subkey name. This is how ProgID and CLSID keys store their friendly
+// names. Subkeys without a string default value are left out.
+func ReadChildDefaults(root registry.Key, keyPath string) (map[string]string, error) {
+    k, err := openKey(root, keyPath, registry.ENUMERATE_SUB_KEYS)
+    if err != nil {
+        return nil, err
+    }
+    defer k.Close()
+
+    subKeys, err := k.ReadSubKeyNames(-1)
+    if err != nil {
+        return nil, err
+    }
+
+    defaults := make(map[string]string, len(subKeys))
+    for _, name := range subKeys {
+        sk, err := openKey(k, name, registry.QUERY_VALUE)
+        if err != nil {
+            return nil, err
+        }
+        value, _, err := sk.GetStringValue("")
+        sk.Close()
+        if err == nil {
+            defaults[name] = value
+        }
+    }
+
+    return defaults, nil
+}
+
+// OpenSubKey opens subPath relative to an already open key. The caller must
+// close the returned key.
+func OpenSubKey(parent registry.Key, subPath string, access uint32) (registry.Key, error) {
+    return openKey(parent, subPath, access)
+}
+
+// KeyPath returns the full NT path of an open key, such as
+// `\REGISTRY\MACHINE\SOFTWARE\Microsoft`. Keys under HKEY_CURRENT_USER are
+// reported under `\REGISTRY\USER\<SID>`. The predefined root keys are
+// pseudo-handles and have no path; pass a key obtained from an open call.
+func KeyPath(k registry.Key) (string, error) {
+    return ntQueryKeyName(k)
+}
+
 // CreateKey creates a new registry key or opens an existing one.
 func CreateKey(root registry.Key, keyPath string) (registry.Key, error) {
-    k, _, err := registry.CreateKey(root, keyPath, registry.ALL_ACCESS)
-    return k, err
+    return createKeyAudited("CreateKey", root, keyPath, registry.ALL_ACCESS)
+}
+
+// ReadStringValue reads a string value (REG_SZ or REG_EXPAND_SZ, unexpanded) from
+// the Windows Registry. A value that exists but holds an empty string, including
+// one stored with zero-length data, yields "" and a nil error; a missing value
+// yields ErrValueNotFound. The raw data is decoded directly, so a string
+// stored without its terminating null by a careless writer is returned in
+// full rather than losing its last character.
+func ReadStringValue(root registry.Key, keyPath, valueName string) (string, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return "", err
+    }
+    defer k.Close()
+
+    raw, typ, err := readRawValue(k, valueName)
+    if err != nil {
+        return "", err
+    }
+    if typ != registry.SZ && typ != registry.EXPAND_SZ {
+        return "", registry.ErrUnexpectedType
+    }
+
+    return decodeString(raw), nil
+}
+
+// ReadStringValueFirst reads the first of valueNames that exists in the key,
+// for settings that were renamed between versions, and returns which name
+// matched together with its data. ErrValueNotFound is returned if none of
+// the values exist.
+func ReadStringValueFirst(root registry.Key, keyPath string, valueNames []string) (name string, value string, err error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return "", "", err
+    }
+    defer k.Close()
+
+    for _, name := range valueNames {
+        raw, typ, err := readRawValue(k, name)
+        if err == registry.ErrNotExist {
+            continue
+        }
+        if err != nil {
+            return "", "", err
+        }
+        if typ != registry.SZ && typ != registry.EXPAND_SZ {
+            return "", "", registry.ErrUnexpectedType
+        }
+        return name, decodeString(raw), nil
+    }
+
+    return "", "", ErrValueNotFound
 }
 
 // ReadStringValueWithDefault reads a string value from the Windows Registry with a default value.
+// The default is returned only if the key or value doesn't exist; see
+// ReadStringValueWithDefaultStrict.
 func ReadStringValueWithDefault(root registry.Key, keyPath, valueName, defaultValue string) (string, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    return ReadStringValueWithDefaultStrict(root, keyPath, valueName, defaultValue)
+}
+
+// ReadStringValueWithDefaultStrict returns defaultValue if the key or value
+// doesn't exist and the value's data otherwise. Every other failure, such as
+// access denied or a value of another type, is returned as an error instead
+// of being masked by the default.
+func ReadStringValueWithDefaultStrict(root registry.Key, keyPath, valueName, defaultValue string) (string, error) {
+    value, _, err := ReadStringValueOrDefault(root, keyPath, valueName, defaultValue)
+    return value, err
+}
+
+// ReadStringValueOrDefault is ReadStringValueWithDefaultStrict that also
+// reports whether the default was used because the key or value doesn't
+// exist, for callers that want to persist the default in that case.
+func ReadStringValueOrDefault(root registry.Key, keyPath, valueName, defaultValue string) (value string, usedDefault bool, err error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err == registry.ErrNotExist {
+        return defaultValue, true, nil
+    }
     if err != nil {
-        return defaultValue, nil // Return the default value if the key or value doesn't exist
+        return "", false, err
+    }
+    defer k.Close()
+
+    value, _, err = k.GetStringValue(valueName)
+    if err == registry.ErrNotExist {
+        return defaultValue, true, nil
+    }
+    if err != nil {
+        return "", false, err
+    }
+
+    return value, false, nil
+}
+
+// ReadStringValueTrimBOM reads a string value and strips a leading UTF-16 byte
+// order mark (U+FEFF) that some programs store along with the text. Use a
+// plain read instead when the value deliberately begins with U+FEFF, for
+// example when it holds the contents of a text file verbatim.
+func ReadStringValueTrimBOM(root registry.Key, keyPath, valueName string) (string, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return "", err
     }
     defer k.Close()
 
     value, _, err := k.GetStringValue(valueName)
     if err != nil {
-        return defaultValue, nil // Return the default value if the value doesn't exist
+        return "", err
     }
 
-    return value, nil
+    return strings.TrimPrefix(value, "\ufeff"), nil
 }
 
 // ReadMultiStringValue reads a multi-string value from the Windows Registry.
 func ReadMultiStringValue(root registry.Key, keyPath, valueName string) ([]string, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return nil, err
     }
@@ -191,8 +759,10 @@ func ReadMultiStringValue(root registry.Key, keyPath, valueName string) ([]strin
 }
 
 // WriteMultiStringValue writes a multi-string value to the Windows Registry.
-func WriteMultiStringValue(root registry.Key, keyPath, valueName string, data []string) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteMultiStringValue(root registry.Key, keyPath, valueName string, data []string) (err error) {
+    defer auditValue("WriteMultiStringValue", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -207,7 +777,7 @@ func WriteMultiStringValue(root registry.Key, keyPath, valueName string, data []
 
 // ReadQWordValue reads a QWORD (64-bit integer) value from the Windows Registry.
 func ReadQWordValue(root registry.Key, keyPath, valueName string) (uint64, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return 0, err
     }
@@ -222,8 +792,10 @@ func ReadQWordValue(root registry.Key, keyPath, valueName string) (uint64, error
 }
 
 // WriteQWordValue writes a QWORD (64-bit integer) value to the Windows Registry.
-func WriteQWordValue(root registry.Key, keyPath, valueName string, data uint64) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteQWordValue(root registry.Key, keyPath, valueName string, data uint64) (err error) {
+    defer auditValue("WriteQWordValue", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -236,9 +808,30 @@ func WriteQWordValue(root registry.Key, keyPath, valueName string, data uint64)
     return nil
 }
 
+// ReadQWordValueTolerant reads a QWORD value like ReadQWordValue, but also accepts
+// an 8-byte REG_BINARY value and decodes it as a little-endian integer, which
+// is how some programs mistakenly store 64-bit values.
+func ReadQWordValueTolerant(root registry.Key, keyPath, valueName string) (uint64, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return 0, err
+    }
+    defer k.Close()
+
+    raw, typ, err := readRawValue(k, valueName)
+    if err != nil {
+        return 0, err
+    }
+    if (typ != registry.QWORD && typ != registry.BINARY) || len(raw) != 8 {
+        return 0, registry.ErrUnexpectedType
+    }
+
+    return binary.LittleEndian.Uint64(raw), nil
+}
+
 // ReadExpandStringValue reads an expandable string value (REG_EXPAND_SZ) from the Windows Registry.
 func ReadExpandStringValue(root registry.Key, keyPath, valueName string) (string, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return "", err
     }
@@ -253,8 +846,10 @@ func ReadExpandStringValue(root registry.Key, keyPath, valueName string) (string
 }
 
 // WriteExpandStringValue writes an expandable string value (REG_EXPAND_SZ) to the Windows Registry.
-func WriteExpandStringValue(root registry.Key, keyPath, valueName, data string) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteExpandStringValue(root registry.Key, keyPath, valueName, data string) (err error) {
+    defer auditValue("WriteExpandStringValue", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -267,9 +862,62 @@ func WriteExpandStringValue(root registry.Key, keyPath, valueName, data string)
     return nil
 }
 
+// ReadExpandStringValueWith reads an expandable string value (REG_EXPAND_SZ) and
+// expands it against env instead of the current process environment.
+func ReadExpandStringValueWith(root registry.Key, keyPath, valueName string, env map[string]string) (string, error) {
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
+    if err != nil {
+        return "", err
+    }
+    defer k.Close()
+
+    value, _, err := k.GetStringValue(valueName)
+    if err != nil {
+        return "", err
+    }
+
+    return ExpandStringWith(value, env), nil
+}
+
+// ExpandStringWith replaces %NAME% references in value with entries from env.
+// Names are matched case-insensitively and references to variables missing
+// from env are left untouched, as Windows does.
+func ExpandStringWith(value string, env map[string]string) string {
+    vars := make(map[string]string, len(env))
+    for name, v := range env {
+        vars[strings.ToUpper(name)] = v
+    }
+
+    var b strings.Builder
+    for {
+        i := strings.IndexByte(value, '%')
+        if i < 0 {
+            break
+        }
+        j := strings.IndexByte(value[i+1:], '%')
+        if j < 0 {
+            break
+        }
+        j += i + 1
+
+        if v, ok := vars[strings.ToUpper(value[i+1:j])]; ok && j > i+1 {
+            b.WriteString(value[:i])
+            b.WriteString(v)
+            value = value[j+1:]
+        } else {
+            // Keep the first '%' and rescan from the second one.
+            b.WriteString(value[:j])
+            value = value[j:]
+        }
+    }
+    b.WriteString(value)
+
+    return b.String()
+}
+
 // ReadInt32Value reads a 32-bit integer value from the Windows Registry.
 func ReadInt32Value(root registry.Key, keyPath, valueName string) (int32, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return 0, err
     }
@@ -284,8 +932,10 @@ func ReadInt32Value(root registry.Key, keyPath, valueName string) (int32, error)
 }
 
 // WriteInt32Value writes a 32-bit integer value to the Windows Registry.
-func WriteInt32Value(root registry.Key, keyPath, valueName string, data int32) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteInt32Value(root registry.Key, keyPath, valueName string, data int32) (err error) {
+    defer auditValue("WriteInt32Value", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -300,7 +950,7 @@ func WriteInt32Value(root registry.Key, keyPath, valueName string, data int32) e
 
 // ReadInt64Value reads a 64-bit integer value from the Windows Registry.
 func ReadInt64Value(root registry.Key, keyPath, valueName string) (int64, error) {
-    k, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+    k, err := openKey(root, keyPath, registry.QUERY_VALUE)
     if err != nil {
         return 0, err
     }
@@ -315,8 +965,10 @@ func ReadInt64Value(root registry.Key, keyPath, valueName string) (int64, error)
 }
 
 // WriteInt64Value writes a 64-bit integer value to the Windows Registry.
-func WriteInt64Value(root registry.Key, keyPath, valueName string, data int64) error {
-    k, err := registry.OpenKey(root, keyPath, registry.WRITE)
+func WriteInt64Value(root registry.Key, keyPath, valueName string, data int64) (err error) {
+    defer auditValue("WriteInt64Value", root, keyPath, valueName, data)(&err)
+
+    k, err := openKey(root, keyPath, registry.WRITE)
     if err != nil {
         return err
     }
@@ -330,6 +982,8 @@ func WriteInt64Value(root registry.Key, keyPath, valueName string, data int64) e
 }
 
 // DeleteKey deletes a registry key and all its subkeys and values.
-func DeleteKey(root registry.Key, keyPath string) error {
+func DeleteKey(root registry.Key, keyPath string) (err error) {
+    defer auditKey("DeleteKey", root, keyPath)(&err)
+
     return registry.DeleteKey(root, keyPath)
 }
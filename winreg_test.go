@@ -0,0 +1,56 @@
+package winreg
+
+import (
+    "fmt"
+    "os"
+    "testing"
+    "time"
+    "unicode/utf16"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+// testKey creates a scratch key under HKEY_CURRENT_USER\Software and removes
+// it when the test ends.
+func testKey(t *testing.T) (registry.Key, string) {
+    t.Helper()
+
+    path := fmt.Sprintf(`Software\winreg-test-%d-%d`, os.Getpid(), time.Now().UnixNano())
+    k, _, err := registry.CreateKey(registry.CURRENT_USER, path, registry.ALL_ACCESS)
+    if err != nil {
+        t.Fatalf("creating %s: %v", path, err)
+    }
+    t.Cleanup(func() {
+        k.Close()
+        if err := deleteKeyTreeAt(registry.CURRENT_USER, path); err != nil {
+            t.Errorf("removing %s: %v", path, err)
+        }
+    })
+
+    return k, path
+}
+
+func TestReadStringValueUnterminated(t *testing.T) {
+    k, path := testKey(t)
+
+    tests := []struct {
+        name string
+        typ  uint32
+        raw  []byte
+        want string
+    }{
+        {"terminated", registry.SZ, szRaw("hello"), "hello"},
+        {"unterminated", registry.SZ, utf16Raw(utf16.Encode([]rune("hello"))...), "hello"},
+        {"unterminated expand", registry.EXPAND_SZ, utf16Raw(utf16.Encode([]rune("%TEMP%"))...), "%TEMP%"},
+        {"empty", registry.SZ, nil, ""},
+    }
+    for _, tt := range tests {
+        if err := regSetValueEx(k, tt.name, tt.typ, tt.raw); err != nil {
+            t.Fatalf("writing %q: %v", tt.name, err)
+        }
+        got, err := ReadStringValue(registry.CURRENT_USER, path, tt.name)
+        if err != nil || got != tt.want {
+            t.Errorf("ReadStringValue(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+        }
+    }
+}
@@ -0,0 +1,118 @@
+package winreg
+
+// RedirectionKind tells how WOW64 treats a key under HKEY_LOCAL_MACHINE.
+type RedirectionKind int
+
+const (
+    // RedirectionUnknown is returned for paths the rules don't cover, such
+    // as paths that already name the WOW6432Node view.
+    RedirectionUnknown RedirectionKind = iota
+
+    // RedirectionRedirected means 32-bit and 64-bit programs see separate
+    // copies of the key; the 32-bit one lives under WOW6432Node.
+    RedirectionRedirected
+
+    // RedirectionShared means both views refer to the same key.
+    RedirectionShared
+)
+
+func (k RedirectionKind) String() string {
+    switch k {
+    case RedirectionRedirected:
+        return "Redirected"
+    case RedirectionShared:
+        return "Shared"
+    }
+    return "Unknown"
+}
+
+// wow64SharedKeys lists the keys under HKLM\SOFTWARE that are shared
+// between the views on Windows 7 and later, together with their subkeys.
+var wow64SharedKeys = []string{
+    `SOFTWARE\Classes`,
+    `SOFTWARE\Microsoft\COM3`,
+    `SOFTWARE\Microsoft\Cryptography\Calais\Current`,
+    `SOFTWARE\Microsoft\Cryptography\Calais\Readers`,
+    `SOFTWARE\Microsoft\Cryptography\Services`,
+    `SOFTWARE\Microsoft\CTF\SystemShared`,
+    `SOFTWARE\Microsoft\CTF\TIP`,
+    `SOFTWARE\Microsoft\DFS`,
+    `SOFTWARE\Microsoft\Driver Signing`,
+    `SOFTWARE\Microsoft\EnterpriseCertificates`,
+    `SOFTWARE\Microsoft\EventSystem`,
+    `SOFTWARE\Microsoft\MSMQ`,
+    `SOFTWARE\Microsoft\Non-Driver Signing`,
+    `SOFTWARE\Microsoft\OLE`,
+    `SOFTWARE\Microsoft\RAS`,
+    `SOFTWARE\Microsoft\RPC`,
+    `SOFTWARE\Microsoft\Shared Tools\MSInfo`,
+    `SOFTWARE\Microsoft\SystemCertificates`,
+    `SOFTWARE\Microsoft\TermServLicensing`,
+    `SOFTWARE\Microsoft\Transaction Server`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\App Paths`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Control Panel\Cursors\Schemes`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\AutoplayHandlers`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\DriveIcons`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\KindMap`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Group Policy`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Policies`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\PreviewHandlers`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Setup`,
+    `SOFTWARE\Microsoft\Windows\CurrentVersion\Telephony\Locations`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Console`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontDpi`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontLink`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontMapper`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Fonts`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontSubstitutes`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Gre_Initialize`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\LanguagePack`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\NetworkCards`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Ports`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Print`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\ProfileList`,
+    `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Time Zones`,
+    `SOFTWARE\Policies`,
+    `SOFTWARE\RegisteredApplications`,
+}
+
+// wow64RedirectedKeys lists the exceptions beneath the shared keys that are
+// redirected after all.
+var wow64RedirectedKeys = []string{
+    `SOFTWARE\Classes\CLSID`,
+    `SOFTWARE\Classes\DirectShow`,
+    `SOFTWARE\Classes\Interface`,
+    `SOFTWARE\Classes\Media Type`,
+    `SOFTWARE\Classes\MediaFoundation`,
+}
+
+// KeyRedirectionKind reports whether WOW64 redirects or shares the key at
+// keyPath, a path relative to HKEY_LOCAL_MACHINE such as
+// `SOFTWARE\Microsoft\Windows\CurrentVersion\Run`. It follows the documented
+// rules for Windows 7 and later: SOFTWARE is redirected apart from a list of
+// shared keys, and keys outside SOFTWARE are always shared. The same rules
+// apply to HKEY_CURRENT_USER\Software\Classes when the path is given as
+// `SOFTWARE\Classes\...`.
+func KeyRedirectionKind(keyPath string) RedirectionKind {
+    keyPath = cleanPath(keyPath)
+    switch {
+    case keyPath == "":
+        return RedirectionUnknown
+    case !hasPathPrefix(keyPath, "SOFTWARE"):
+        return RedirectionShared
+    case hasPathPrefix(keyPath, `SOFTWARE\WOW6432Node`), hasPathPrefix(keyPath, `SOFTWARE\Classes\WOW6432Node`):
+        return RedirectionUnknown
+    }
+
+    for _, p := range wow64RedirectedKeys {
+        if hasPathPrefix(keyPath, p) {
+            return RedirectionRedirected
+        }
+    }
+    for _, p := range wow64SharedKeys {
+        if hasPathPrefix(keyPath, p) {
+            return RedirectionShared
+        }
+    }
+    return RedirectionRedirected
+}
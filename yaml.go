@@ -0,0 +1,460 @@
+package winreg
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// writeYAMLValue writes the type and data entries of a value as lines
+// indented by indent. The data is encoded as jsonValue does, with MULTI_SZ
+// as a block sequence.
+func writeYAMLValue(bw *bufio.Writer, typ uint32, raw []byte, indent string) {
+    bw.WriteString(indent + "type: " + TypeName(typ) + "\n" + indent + "data:")
+
+    switch data := decodeValue(typ, raw).(type) {
+    case string:
+        bw.WriteString(" " + yamlString(data) + "\n")
+    case []string:
+        if len(data) == 0 {
+            bw.WriteString(" []\n")
+            break
+        }
+        bw.WriteString("\n")
+        for _, s := range data {
+            bw.WriteString(indent + "  - " + yamlString(s) + "\n")
+        }
+    case uint32:
+        bw.WriteString(" " + strconv.FormatUint(uint64(data), 10) + "\n")
+    case uint64:
+        bw.WriteString(" " + strconv.FormatUint(data, 10) + "\n")
+    case []byte:
+        bw.WriteString(` "` + base64.StdEncoding.EncodeToString(data) + "\"\n")
+    }
+}
+
+// yamlString returns s as a YAML double-quoted scalar. A JSON string literal
+// is one already, except that YAML also wants DEL, the C1 control characters
+// and the non-characters escaped.
+func yamlString(s string) string {
+    q := jsonString(s)
+    if !strings.ContainsFunc(q, needsYAMLEscape) {
+        return q
+    }
+
+    var b strings.Builder
+    for _, c := range q {
+        if needsYAMLEscape(c) {
+            fmt.Fprintf(&b, `\u%04x`, c)
+        } else {
+            b.WriteRune(c)
+        }
+    }
+    return b.String()
+}
+
+func needsYAMLEscape(c rune) bool {
+    return c >= 0x7f && c <= 0x9f || c == 0xfeff || c == 0xfffe || c == 0xffff
+}
+
+// yamlKind is the kind of a yamlNode.
+type yamlKind int
+
+const (
+    yamlScalar yamlKind = iota
+    yamlMapping
+    yamlSequence
+)
+
+// yamlNode is a node of a document read by parseYAML. Scalars are held in
+// their JSON form so that values can be decoded with decodeJSONValue.
+type yamlNode struct {
+    kind   yamlKind
+    line   int
+    value  json.RawMessage      // scalar
+    keys   []string             // mapping keys in document order
+    fields map[string]*yamlNode // mapping
+    items  []*yamlNode          // sequence
+}
+
+// field returns the entry name of a mapping, or nil if there is none.
+func (n *yamlNode) field(name string) *yamlNode {
+    return n.fields[name]
+}
+
+// isNull reports whether n is an empty entry, such as "subkeys:" without
+// anything beneath it.
+func (n *yamlNode) isNull() bool {
+    return n.kind == yamlScalar && string(n.value) == "null"
+}
+
+// toJSON returns the JSON form of n.
+func (n *yamlNode) toJSON() json.RawMessage {
+    switch n.kind {
+    case yamlMapping:
+        var buf bytes.Buffer
+        buf.WriteByte('{')
+        for i, key := range n.keys {
+            if i > 0 {
+                buf.WriteByte(',')
+            }
+            buf.WriteString(jsonString(key) + ":")
+            buf.Write(n.fields[key].toJSON())
+        }
+        buf.WriteByte('}')
+        return buf.Bytes()
+    case yamlSequence:
+        var buf bytes.Buffer
+        buf.WriteByte('[')
+        for i, item := range n.items {
+            if i > 0 {
+                buf.WriteByte(',')
+            }
+            buf.Write(item.toJSON())
+        }
+        buf.WriteByte(']')
+        return buf.Bytes()
+    }
+    return n.value
+}
+
+// yamlLine is a line of a YAML document that is neither blank nor a comment.
+type yamlLine struct {
+    num    int
+    indent int
+    text   string
+}
+
+// yamlParser reads the subset of YAML that ExportToYAML writes and that is
+// commonly used when editing such documents by hand: block mappings and
+// sequences, empty or single-line flow collections of scalars, plain,
+// single- and double-quoted scalars and comments. Anchors, tags, block
+// scalars and multi-line flow collections are rejected. Double-quoted
+// strings may only use the escapes that JSON has too.
+type yamlParser struct {
+    lines []yamlLine
+    pos   int
+}
+
+// parseYAML reads a whole document from r, which may be UTF-8 or UTF-16LE
+// with a byte order mark.
+func parseYAML(r io.Reader) (*yamlNode, error) {
+    p := &yamlParser{}
+    rr := newRegFileReader(r)
+    for num := 1; ; num++ {
+        line, err := rr.ReadLine()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        text := strings.TrimLeft(line, " ")
+        indent := len(line) - len(text)
+        if strings.HasPrefix(text, "\t") {
+            return nil, yamlError(num, "tabs cannot be used for indentation")
+        }
+        text = strings.TrimSpace(stripYAMLComment(text))
+        if text == "" || indent == 0 && (text == "---" || text == "..." || text[0] == '%') {
+            continue
+        }
+        p.lines = append(p.lines, yamlLine{num: num, indent: indent, text: text})
+    }
+
+    if len(p.lines) == 0 {
+        return &yamlNode{kind: yamlScalar, value: json.RawMessage("null")}, nil
+    }
+    n, err := p.parseBlock(p.lines[0].indent)
+    if err != nil {
+        return nil, err
+    }
+    if p.pos < len(p.lines) {
+        return nil, yamlError(p.lines[p.pos].num, "unexpected indentation")
+    }
+    return n, nil
+}
+
+// parseBlock parses the block mapping or sequence starting at the current
+// line, whose entries are indented by indent.
+func (p *yamlParser) parseBlock(indent int) (*yamlNode, error) {
+    if isYAMLSequenceItem(p.lines[p.pos].text) {
+        return p.parseSequence(indent)
+    }
+    return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseMapping(indent int) (*yamlNode, error) {
+    n := &yamlNode{kind: yamlMapping, line: p.lines[p.pos].num, fields: make(map[string]*yamlNode)}
+
+    for p.pos < len(p.lines) {
+        l := p.lines[p.pos]
+        if l.indent < indent || l.indent == indent && isYAMLSequenceItem(l.text) {
+            break
+        }
+        if l.indent > indent {
+            return nil, yamlError(l.num, "unexpected indentation")
+        }
+        p.pos++
+
+        key, rest, err := splitYAMLKey(l)
+        if err != nil {
+            return nil, err
+        }
+        if _, dup := n.fields[key]; dup {
+            return nil, yamlError(l.num, fmt.Sprintf("duplicate key %q", key))
+        }
+
+        // A sequence may sit at the same indentation as the key that holds
+        // it.
+        var v *yamlNode
+        if rest == "" && p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+            v, err = p.parseSequence(indent)
+        } else {
+            v, err = p.parseValue(indent, rest, l.num)
+        }
+        if err != nil {
+            return nil, err
+        }
+        n.keys = append(n.keys, key)
+        n.fields[key] = v
+    }
+
+    return n, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) (*yamlNode, error) {
+    n := &yamlNode{kind: yamlSequence, line: p.lines[p.pos].num}
+
+    for p.pos < len(p.lines) {
+        l := p.lines[p.pos]
+        if l.indent < indent || l.indent == indent && !isYAMLSequenceItem(l.text) {
+            break
+        }
+        if l.indent > indent {
+            return nil, yamlError(l.num, "unexpected indentation")
+        }
+        p.pos++
+
+        v, err := p.parseValue(indent, strings.TrimSpace(l.text[1:]), l.num)
+        if err != nil {
+            return nil, err
+        }
+        n.items = append(n.items, v)
+    }
+
+    return n, nil
+}
+
+// parseValue parses the value of a mapping entry or sequence item whose line
+// is indented by indent. rest is what follows the key or dash on that line;
+// if it is empty, the value is the block indented beneath it, if any.
+func (p *yamlParser) parseValue(indent int, rest string, num int) (*yamlNode, error) {
+    if rest != "" {
+        return parseYAMLFlow(rest, num)
+    }
+    if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+        return p.parseBlock(p.lines[p.pos].indent)
+    }
+    return &yamlNode{kind: yamlScalar, line: num, value: json.RawMessage("null")}, nil
+}
+
+// parseYAMLFlow parses a value written on a single line: a scalar, {} or a
+// flow sequence of scalars.
+func parseYAMLFlow(text string, num int) (*yamlNode, error) {
+    switch {
+    case text == "{}":
+        return &yamlNode{kind: yamlMapping, line: num, fields: make(map[string]*yamlNode)}, nil
+    case text[0] == '[':
+        if !strings.HasSuffix(text, "]") {
+            return nil, yamlError(num, "flow sequences must end on the same line")
+        }
+        n := &yamlNode{kind: yamlSequence, line: num}
+        items, err := splitYAMLFlow(text[1:len(text)-1], num)
+        if err != nil {
+            return nil, err
+        }
+        for _, item := range items {
+            v, err := parseYAMLScalar(item, num)
+            if err != nil {
+                return nil, err
+            }
+            n.items = append(n.items, &yamlNode{kind: yamlScalar, line: num, value: v})
+        }
+        return n, nil
+    }
+
+    v, err := parseYAMLScalar(text, num)
+    if err != nil {
+        return nil, err
+    }
+    return &yamlNode{kind: yamlScalar, line: num, value: v}, nil
+}
+
+// parseYAMLScalar returns the JSON form of a scalar. Plain scalars are
+// resolved as in YAML's core schema, so unquoted digits are numbers; hex
+// numbers are accepted for convenience with DWORD and QWORD data.
+func parseYAMLScalar(text string, num int) (json.RawMessage, error) {
+    if text[0] == '"' || text[0] == '\'' {
+        s, rest, err := parseYAMLQuoted(text, num)
+        if err != nil {
+            return nil, err
+        }
+        if rest != "" {
+            return nil, yamlError(num, "unexpected text after quoted string")
+        }
+        return json.RawMessage(jsonString(s)), nil
+    }
+
+    if strings.ContainsAny(text[:1], "[]{}&*!|>@`") {
+        return nil, yamlError(num, fmt.Sprintf("unsupported YAML syntax %q", text))
+    }
+    if strings.Contains(text, ": ") || strings.HasSuffix(text, ":") {
+        return nil, yamlError(num, "mappings are only supported in block form")
+    }
+
+    switch text {
+    case "~", "null", "Null", "NULL":
+        return json.RawMessage("null"), nil
+    case "true", "True", "TRUE":
+        return json.RawMessage("true"), nil
+    case "false", "False", "FALSE":
+        return json.RawMessage("false"), nil
+    }
+    if _, err := strconv.ParseUint(text, 10, 64); err == nil {
+        return json.RawMessage(text), nil
+    }
+    if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+        return json.RawMessage(text), nil
+    }
+    if hex, ok := strings.CutPrefix(text, "0x"); ok {
+        if v, err := strconv.ParseUint(hex, 16, 64); err == nil {
+            return json.RawMessage(strconv.FormatUint(v, 10)), nil
+        }
+    }
+    return json.RawMessage(jsonString(text)), nil
+}
+
+// parseYAMLQuoted parses the quoted string at the start of text and returns
+// it together with the trimmed remainder of text.
+func parseYAMLQuoted(text string, num int) (s, rest string, err error) {
+    quote := text[0]
+    for i := 1; i < len(text); i++ {
+        switch {
+        case quote == '"' && text[i] == '\\':
+            i++
+        case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+            i++
+        case text[i] == quote:
+            rest = strings.TrimSpace(text[i+1:])
+            if quote == '\'' {
+                return strings.ReplaceAll(text[1:i], "''", "'"), rest, nil
+            }
+            if err := json.Unmarshal([]byte(text[:i+1]), &s); err != nil {
+                return "", "", yamlError(num, "unsupported escape in double-quoted string")
+            }
+            return s, rest, nil
+        }
+    }
+    return "", "", yamlError(num, "unterminated quoted string")
+}
+
+// splitYAMLKey splits a mapping entry into its key and the trimmed text
+// after the colon.
+func splitYAMLKey(l yamlLine) (key, rest string, err error) {
+    if l.text[0] == '"' || l.text[0] == '\'' {
+        key, rest, err = parseYAMLQuoted(l.text, l.num)
+        if err != nil {
+            return "", "", err
+        }
+        if rest == ":" || strings.HasPrefix(rest, ": ") {
+            return key, strings.TrimSpace(rest[1:]), nil
+        }
+        return "", "", yamlError(l.num, "expected a colon after the key")
+    }
+
+    if i := strings.Index(l.text, ": "); i >= 0 {
+        return strings.TrimSpace(l.text[:i]), strings.TrimSpace(l.text[i+2:]), nil
+    }
+    if key, ok := strings.CutSuffix(l.text, ":"); ok {
+        return strings.TrimSpace(key), "", nil
+    }
+    return "", "", yamlError(l.num, "expected a mapping entry")
+}
+
+// splitYAMLFlow splits the inside of a flow sequence at the commas that are
+// not quoted.
+func splitYAMLFlow(text string, num int) ([]string, error) {
+    var items []string
+    var quote byte
+    start := 0
+    for i := 0; i < len(text); i++ {
+        c := text[i]
+        switch {
+        case quote == '"' && c == '\\':
+            i++
+        case quote == '\'' && c == '\'' && i+1 < len(text) && text[i+1] == '\'':
+            i++
+        case quote != 0:
+            if c == quote {
+                quote = 0
+            }
+        case (c == '"' || c == '\'') && strings.TrimSpace(text[start:i]) == "":
+            quote = c
+        case c == ',':
+            items = append(items, strings.TrimSpace(text[start:i]))
+            start = i + 1
+        }
+    }
+    if quote != 0 {
+        return nil, yamlError(num, "unterminated quoted string")
+    }
+    if last := strings.TrimSpace(text[start:]); last != "" || len(items) > 0 {
+        items = append(items, last)
+    }
+    for _, item := range items {
+        if item == "" {
+            return nil, yamlError(num, "empty entry in flow sequence")
+        }
+    }
+    return items, nil
+}
+
+// stripYAMLComment removes a comment from a line. A # starts a comment at
+// the beginning of the line or after white space, outside of quoted
+// scalars. Quotes inside a plain scalar, as in don't, are ordinary
+// characters.
+func stripYAMLComment(text string) string {
+    var quote byte
+    for i := 0; i < len(text); i++ {
+        c := text[i]
+        switch {
+        case quote == '"' && c == '\\':
+            i++
+        case quote == '\'' && c == '\'' && i+1 < len(text) && text[i+1] == '\'':
+            i++
+        case quote != 0:
+            if c == quote {
+                quote = 0
+            }
+        case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[,", text[i-1]) >= 0):
+            quote = c
+        case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+            return text[:i]
+        }
+    }
+    return text
+}
+
+func isYAMLSequenceItem(text string) bool {
+    return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func yamlError(num int, msg string) error {
+    return fmt.Errorf("winreg: YAML line %d: %s", num, msg)
+}
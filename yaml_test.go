@@ -0,0 +1,180 @@
+package winreg
+
+import (
+    "bufio"
+    "reflect"
+    "strings"
+    "testing"
+
+    "golang.org/x/sys/windows/registry"
+)
+
+func TestYAMLValueRoundTrip(t *testing.T) {
+    tests := []struct {
+        name string
+        typ  uint32
+        data any
+    }{
+        {"sz", registry.SZ, `plain text`},
+        {"sz special", registry.SZ, "quote \" hash # colon: tab\t line\nbreak"},
+        {"sz controls", registry.SZ, "del \x7f c1 \u0085 bom \ufeff"},
+        {"sz astral", registry.SZ, "g clef \U0001D11E"},
+        {"sz empty", registry.SZ, ""},
+        {"expand sz", registry.EXPAND_SZ, `%SystemRoot%\system32`},
+        {"multi sz", registry.MULTI_SZ, []string{"a", "it's", "- dash", "# hash"}},
+        {"multi sz empty", registry.MULTI_SZ, []string{}},
+        {"dword", registry.DWORD, uint32(0xffffffff)},
+        {"qword", registry.QWORD, uint64(1) << 63},
+        {"binary", registry.BINARY, []byte{0, 1, 0xfe, 0xff}},
+        {"none", registry.NONE, []byte{}},
+    }
+    for _, tt := range tests {
+        raw, err := encodeValue(tt.typ, tt.data)
+        if err != nil {
+            t.Fatalf("%s: encodeValue: %v", tt.name, err)
+        }
+
+        var buf strings.Builder
+        bw := bufio.NewWriter(&buf)
+        bw.WriteString("values:\n  " + yamlString(tt.name) + ":\n")
+        writeYAMLValue(bw, tt.typ, raw, "    ")
+        bw.Flush()
+
+        doc, err := parseYAML(strings.NewReader(buf.String()))
+        if err != nil {
+            t.Errorf("%s: parseYAML(%q): %v", tt.name, buf.String(), err)
+            continue
+        }
+        v := doc.field("values").field(tt.name)
+        typeName, err := unmarshalJSONAs[string](v.field("type").toJSON())
+        if err != nil {
+            t.Errorf("%s: type: %v", tt.name, err)
+            continue
+        }
+        typ, got, err := decodeJSONValue(typeName, v.field("data").toJSON())
+        if err != nil {
+            t.Errorf("%s: decodeJSONValue: %v", tt.name, err)
+            continue
+        }
+        if typ != tt.typ || !reflect.DeepEqual(decodeValue(typ, got), decodeValue(tt.typ, raw)) {
+            t.Errorf("%s: round trip of %q = %s %q, want %s %q", tt.name, buf.String(),
+                TypeName(typ), decodeValue(typ, got), TypeName(tt.typ), tt.data)
+        }
+    }
+}
+
+// yamlExample is the document shown on ExportToYAML.
+const yamlExample = `values:
+  "Name":
+    type: REG_SZ
+    data: "text"
+  "Path":
+    type: REG_MULTI_SZ
+    data:
+      - "a"
+      - "b"
+subkeys:
+  "Child":
+    values: {}
+    subkeys: {}
+`
+
+func TestYAMLExportExample(t *testing.T) {
+    // Build the document the way exportYAMLKey does.
+    var buf strings.Builder
+    bw := bufio.NewWriter(&buf)
+    bw.WriteString("values:\n")
+    for _, v := range []struct {
+        name string
+        typ  uint32
+        data any
+    }{
+        {"Name", registry.SZ, "text"},
+        {"Path", registry.MULTI_SZ, []string{"a", "b"}},
+    } {
+        raw, err := encodeValue(v.typ, v.data)
+        if err != nil {
+            t.Fatal(err)
+        }
+        bw.WriteString("  " + yamlString(v.name) + ":\n")
+        writeYAMLValue(bw, v.typ, raw, "    ")
+    }
+    bw.WriteString("subkeys:\n  " + yamlString("Child") + ":\n    values: {}\n    subkeys: {}\n")
+    bw.Flush()
+    if buf.String() != yamlExample {
+        t.Fatalf("export wrote\n%s\nwant\n%s", buf.String(), yamlExample)
+    }
+
+    doc, err := parseYAML(strings.NewReader(yamlExample))
+    if err != nil {
+        t.Fatal(err)
+    }
+    values := doc.field("values")
+    for _, name := range values.keys {
+        v := values.fields[name]
+        typeName, err := unmarshalJSONAs[string](v.field("type").toJSON())
+        if err != nil {
+            t.Fatalf("%s: type: %v", name, err)
+        }
+        if _, _, err := decodeJSONValue(typeName, v.field("data").toJSON()); err != nil {
+            t.Errorf("%s: decodeJSONValue: %v", name, err)
+        }
+    }
+    if child := doc.field("subkeys").field("Child"); child == nil || child.field("values") == nil {
+        t.Errorf("subkeys.Child = %+v", child)
+    }
+}
+
+func TestParseYAML(t *testing.T) {
+    tests := []struct {
+        name string
+        doc  string
+        want string // JSON form of the document; empty for an error
+    }{
+        {"empty", "", `null`},
+        {"exported", "values:\n  \"a\":\n    type: REG_DWORD\n    data: 1\nsubkeys: {}\n",
+            `{"values":{"a":{"type":"REG_DWORD","data":1}},"subkeys":{}}`},
+        {"plain scalars", "a: hello world\nb: don't\nc: 12\nd: -3\ne: true\nf: ~\n",
+            `{"a":"hello world","b":"don't","c":12,"d":-3,"e":true,"f":null}`},
+        {"hex number", "a: 0x1F\nb: 0xZZ\n", `{"a":31,"b":"0xZZ"}`},
+        {"quoted digits", "a: \"12\"\nb: '12'\n", `{"a":"12","b":"12"}`},
+        {"single quote escape", "a: 'it''s # x'\n", `{"a":"it's # x"}`},
+        {"double quote escape", `a: "say \"hi\" # x"` + "\n", `{"a":"say \"hi\" # x"}`},
+        {"comments", "# header\na: 1 # trailing\n  # indented\nb: x#y\n", `{"a":1,"b":"x#y"}`},
+        {"quoted keys", "\"a b\": 1\n'c''d': 2\n", `{"a b":1,"c'd":2}`},
+        {"flow sequence", "a: [x, 'y, z', \"w\", 'it''s, ok']\nb: []\n", `{"a":["x","y, z","w","it's, ok"],"b":[]}`},
+        {"block sequence", "a:\n  - x\n  - 'y'\n", `{"a":["x","y"]}`},
+        {"same indent sequence", "a:\n- x\n- y\nb: 1\n", `{"a":["x","y"],"b":1}`},
+        {"empty entry", "a:\nb: 1\n", `{"a":null,"b":1}`},
+        {"document markers", "---\na: 1\n...\n", `{"a":1}`},
+        {"crlf", "a: 1\r\nb: 2\r\n", `{"a":1,"b":2}`},
+
+        {"tab indent", "a:\n\tb: 1\n", ""},
+        {"duplicate key", "a: 1\na: 2\n", ""},
+        {"bad indent", "a: 1\n  b: 2\n", ""},
+        {"unterminated quote", "a: 'x\n", ""},
+        {"unterminated flow", "a: [x, y\n", ""},
+        {"empty flow entry", "a: [x, , y]\n", ""},
+        {"flow mapping", "a: {b: 1}\n", ""},
+        {"anchor", "a: &x 1\n", ""},
+        {"block scalar", "a: |\n  text\n", ""},
+        {"text after quote", "a: 'x' y\n", ""},
+        {"bad escape", `a: "\x41"` + "\n", ""},
+    }
+    for _, tt := range tests {
+        doc, err := parseYAML(strings.NewReader(tt.doc))
+        if tt.want == "" {
+            if err == nil {
+                t.Errorf("%s: parseYAML(%q) = %s, want an error", tt.name, tt.doc, doc.toJSON())
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("%s: parseYAML(%q): %v", tt.name, tt.doc, err)
+            continue
+        }
+        if got := string(doc.toJSON()); got != tt.want {
+            t.Errorf("%s: parseYAML(%q) = %s, want %s", tt.name, tt.doc, got, tt.want)
+        }
+    }
+}